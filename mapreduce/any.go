@@ -0,0 +1,104 @@
+package mapreduce
+
+import (
+	"context"
+	"strconv"
+	"sync"
+)
+
+// MRInputAny is the counterpart of MRInput for MapReduceAny: a key with values of any type.
+type MRInputAny struct {
+	Key    string
+	Values []interface{}
+}
+
+// MapFuncAny is the counterpart of MapFunc for MapReduceAny.
+type MapFuncAny func(input MRInputAny, collectChl chan MRInputAny, doneChl chan struct{})
+
+// ReduceFuncAny is the counterpart of ReduceFunc for MapReduceAny.
+type ReduceFuncAny func(input MRInputAny, collectChl chan MRInputAny, doneChl chan struct{})
+
+// MapReduceAny is like TryMapReduce, but for values of any type, such as structs passed between stages, which flow
+// through the job as they are, without being serialized. Values are grouped by key as usual. Nothing checks their
+// types: it is up to the map and reduce functions to agree on them, and to assert them correctly. Options that look
+// at values as strings, such as WithValueDedup, WithPerKeyOutput and WithFinalizer, see opaque placeholders in their
+// place, and so aren't useful here.
+func MapReduceAny(input []MRInputAny, mapFunc MapFuncAny, reduceFunc ReduceFuncAny, opts ...Option) (
+	result map[string][]interface{}, err error) {
+	values := &anyValues{}
+	stringInput := make([]MRInput, len(input))
+	for i, kv := range input {
+		stringInput[i] = values.store(kv)
+	}
+
+	out := run(context.TODO(), stringInput, []MapFunc{values.adapt(mapFunc)}, values.adapt(reduceFunc), newConfig(opts))
+
+	result = make(map[string][]interface{}, len(out.result))
+	for key, ids := range out.result {
+		result[key] = values.load(MRInput{Key: key, Values: ids}).Values
+	}
+	return result, out.err
+}
+
+// anyValues holds the values of a MapReduceAny job, each of which goes through the job as its index in values.
+type anyValues struct {
+	sync.Mutex
+	values []interface{}
+}
+
+// store records kv's values, returning kv with their indexes in place of them.
+func (s *anyValues) store(kv MRInputAny) MRInput {
+	ids := make([]string, len(kv.Values))
+	s.Lock()
+	defer s.Unlock()
+	for i, value := range kv.Values {
+		ids[i] = strconv.Itoa(len(s.values))
+		s.values = append(s.values, value)
+	}
+	return MRInput{Key: kv.Key, Values: ids}
+}
+
+// load is the inverse of store. Values that aren't the index of a stored value, such as those added by an option,
+// load as nil.
+func (s *anyValues) load(kv MRInput) MRInputAny {
+	values := make([]interface{}, len(kv.Values))
+	s.Lock()
+	defer s.Unlock()
+	for i, id := range kv.Values {
+		if n, err := strconv.Atoi(id); err == nil && n >= 0 && n < len(s.values) {
+			values[i] = s.values[n]
+		}
+	}
+	return MRInputAny{Key: kv.Key, Values: values}
+}
+
+// adapt turns fn into a function that the job can run, which converts fn's input and relays what fn emits to the
+// collector. The relay runs until fn signals done, which fn may do after it has returned, or until fn panics.
+func (s *anyValues) adapt(fn func(MRInputAny, chan MRInputAny, chan struct{})) func(MRInput, chan MRInput,
+	chan struct{}) {
+	return func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		anyCollectChl, anyDoneChl := make(chan MRInputAny), make(chan struct{}, 1)
+		stop := make(chan struct{})
+		go func() {
+			for {
+				select {
+				case kv := <-anyCollectChl:
+					collectChl <- s.store(kv)
+				case <-anyDoneChl:
+					doneChl <- struct{}{}
+					return
+				case <-stop:
+					return
+				}
+			}
+		}()
+		defer func() {
+			if r := recover(); r != nil {
+				// Leave reporting the panic, and signalling done, to the task that ran this.
+				close(stop)
+				panic(r)
+			}
+		}()
+		fn(s.load(input), anyCollectChl, anyDoneChl)
+	}
+}
@@ -0,0 +1,76 @@
+package mapreduce
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type player struct {
+	Name  string
+	Team  string
+	Score int
+}
+
+type teamTotal struct {
+	Players int
+	Score   int
+}
+
+func TestMapReduceAny(t *testing.T) {
+	input := []MRInputAny{
+		{Key: "game1", Values: []interface{}{player{"ann", "red", 3}, player{"bob", "blue", 1}}},
+		{Key: "game2", Values: []interface{}{player{"cat", "red", 2}}},
+	}
+	// Groups players by team.
+	mapFunc := func(input MRInputAny, collectChl chan MRInputAny, doneChl chan struct{}) {
+		for _, value := range input.Values {
+			p := value.(player)
+			collectChl <- MRInputAny{Key: p.Team, Values: []interface{}{p}}
+		}
+		doneChl <- struct{}{}
+	}
+	// Totals each team's players and scores.
+	reduceFunc := func(input MRInputAny, collectChl chan MRInputAny, doneChl chan struct{}) {
+		var total teamTotal
+		for _, value := range input.Values {
+			total.Players++
+			total.Score += value.(player).Score
+		}
+		collectChl <- MRInputAny{Key: input.Key, Values: []interface{}{total}}
+		doneChl <- struct{}{}
+	}
+
+	result, err := MapReduceAny(input, mapFunc, reduceFunc)
+
+	if err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	expected := map[string][]interface{}{"red": {teamTotal{2, 5}}, "blue": {teamTotal{1, 1}}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
+
+func TestMapReduceAnyPanic(t *testing.T) {
+	input := []MRInputAny{{Key: "a", Values: []interface{}{1}}, {Key: "b", Values: []interface{}{"two"}}}
+	// Asserts the wrong type for "b"'s value.
+	mapFunc := func(input MRInputAny, collectChl chan MRInputAny, doneChl chan struct{}) {
+		collectChl <- MRInputAny{Key: input.Key, Values: []interface{}{input.Values[0].(int) * 10}}
+		doneChl <- struct{}{}
+	}
+	reduceFunc := func(input MRInputAny, collectChl chan MRInputAny, doneChl chan struct{}) {
+		collectChl <- input
+		doneChl <- struct{}{}
+	}
+
+	result, err := MapReduceAny(input, mapFunc, reduceFunc)
+
+	var taskErr *TaskError
+	if !errors.As(err, &taskErr) || taskErr.Key != "b" {
+		t.Errorf("Expected a TaskError for <b>; Got <%v>", err)
+	}
+	if !reflect.DeepEqual(map[string][]interface{}{"a": {10}}, result) {
+		t.Errorf("Expected <map[a:[10]]>; Got <%v>", result)
+	}
+}
@@ -0,0 +1,72 @@
+package mapreduce
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// reduceBalanced runs reduce on each key of intermediate under WithBalancedReduce: the keys are assigned to lanes by
+// balanceKeys, and each lane waits for one reducer to signal done before submitting the next. Each reducer's done
+// signal is passed on to doneChl.
+func (j *job) reduceBalanced(intermediate map[string][]string, reduce func(MRInput, chan struct{}),
+	doneChl chan struct{}) {
+	var wg sync.WaitGroup
+	for _, lane := range balanceKeys(intermediate, reduceWorkers(j.cfg.reduceExecutor)) {
+		wg.Add(1)
+		go func(lane []string) {
+			defer wg.Done()
+			laneDoneChl := make(chan struct{}, 1)
+			for _, key := range lane {
+				reduce(MRInput{Key: key, Values: intermediate[key]}, laneDoneChl)
+				<-laneDoneChl
+				doneChl <- struct{}{}
+			}
+		}(lane)
+	}
+	wg.Wait()
+}
+
+// balanceKeys splits the keys of intermediate into at most workers lanes with about the same total number of values
+// each. Keys are taken in descending order of their number of values, ties broken by key so that the split is
+// deterministic, and each goes to the lane with the fewest values so far, which puts every lane's largest keys
+// first.
+func balanceKeys(intermediate map[string][]string, workers int) [][]string {
+	keys := make([]string, 0, len(intermediate))
+	for key := range intermediate {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		na, nb := len(intermediate[keys[a]]), len(intermediate[keys[b]])
+		if na != nb {
+			return na > nb
+		}
+		return keys[a] < keys[b]
+	})
+
+	if workers > len(keys) {
+		workers = len(keys)
+	}
+	lanes := make([][]string, workers)
+	loads := make([]int, workers)
+	for _, key := range keys {
+		lightest := 0
+		for i := range loads {
+			if loads[i] < loads[lightest] {
+				lightest = i
+			}
+		}
+		lanes[lightest] = append(lanes[lightest], key)
+		loads[lightest] += len(intermediate[key])
+	}
+	return lanes
+}
+
+// reduceWorkers returns how many reducers executor can run at once: the size of a Pool, even one held back by a
+// JobHandle, otherwise GOMAXPROCS.
+func reduceWorkers(executor Executor) int {
+	if p, ok := unwrapExecutor(executor).(*Pool); ok && p.size > 0 {
+		return p.size
+	}
+	return runtime.GOMAXPROCS(0)
+}
@@ -0,0 +1,125 @@
+package mapreduce
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// skewedIntermediate has a few keys with many values and many keys with few.
+func skewedIntermediate() map[string][]string {
+	intermediate := make(map[string][]string)
+	for i := 0; i < 100; i++ {
+		n := 1 + i%5
+		if i%25 == 0 {
+			n = 500
+		}
+		intermediate[fmt.Sprintf("key%03d", i)] = make([]string, n)
+	}
+	return intermediate
+}
+
+func TestBalanceKeys(t *testing.T) {
+	intermediate := skewedIntermediate()
+	const workers = 4
+
+	balanced := make([]int, workers)
+	for i, lane := range balanceKeys(intermediate, workers) {
+		for _, key := range lane {
+			balanced[i] += len(intermediate[key])
+		}
+	}
+	// The naive assignment is by hash partition.
+	naive := make([]int, workers)
+	for key, values := range intermediate {
+		naive[PartitionOf(key, workers)] += len(values)
+	}
+
+	spread := func(loads []int) int {
+		min, max := loads[0], loads[0]
+		for _, load := range loads {
+			if load < min {
+				min = load
+			}
+			if load > max {
+				max = load
+			}
+		}
+		return max - min
+	}
+	if spread(balanced) >= spread(naive) {
+		t.Errorf("Expected more even loads than <%v>; Got <%v>", naive, balanced)
+	}
+	// Four workers get one of the four large keys each, and the small keys even out the rest.
+	if spread(balanced) > 5 {
+		t.Errorf("Expected loads within 5 values of each other; Got <%v>", balanced)
+	}
+}
+
+func TestWithBalancedReduce(t *testing.T) {
+	var input []MRInput
+	for key, values := range skewedIntermediate() {
+		input = append(input, MRInput{Key: key, Values: []string{strings.Repeat(key+" ", len(values))}})
+	}
+	pool := NewPool(4)
+	defer pool.Close()
+
+	expected := MapReduce(input, wordMap, countReduce)
+	result := MapReduce(input, wordMap, countReduce, WithBalancedReduce(), WithReduceExecutor(pool))
+
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
+
+func TestReduceWorkers(t *testing.T) {
+	// More goroutines than GOMAXPROCS, so that the pool's size can't be mistaken for the default.
+	size := runtime.GOMAXPROCS(0) + 2
+	pool := NewPool(size)
+	defer pool.Close()
+
+	// A JobHandle's pause gate wraps the pool, which still sets the number of lanes.
+	for _, executor := range []Executor{pool, pausableExecutor{Executor: pool, gate: &pauseGate{}}} {
+		if n := reduceWorkers(executor); n != size {
+			t.Errorf("Expected <%d>; Got <%d>", size, n)
+		}
+	}
+}
+
+func TestWithBalancedReduceSequential(t *testing.T) {
+	var mu sync.Mutex
+	var keys []string
+	var running, overlaps int32
+	reduceFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		if atomic.AddInt32(&running, 1) > 1 {
+			atomic.AddInt32(&overlaps, 1)
+		}
+		mu.Lock()
+		keys = append(keys, input.Key)
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		countReduce(input, collectChl, doneChl)
+	}
+
+	// Several lanes, and keys with different numbers of values, which WithBalancedReduce alone would reduce at once
+	// and largest first.
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	input := []MRInput{{Key: "line1", Values: []string{"a b b c c c d d d d e f f"}}}
+
+	MapReduce(input, wordMap, reduceFunc, WithSequential(), WithBalancedReduce())
+
+	// WithSequential wins: one reducer at a time, in key order.
+	if overlaps != 0 {
+		t.Errorf("Expected no reducers to overlap; Got <%d>", overlaps)
+	}
+	if !sort.StringsAreSorted(keys) {
+		t.Errorf("Expected the keys to be reduced in order; Got <%v>", keys)
+	}
+}
@@ -0,0 +1,35 @@
+package mapreduce
+
+import "strconv"
+
+// BatchInputs splits input into n contiguous batches whose sizes differ by at most one, and returns one MRInput per
+// batch. Passed to MapReduce in place of input, they make the job run n map tasks, each of which runs the mapper over
+// the inputs of its batch in turn, instead of one task per input. This bounds the number of mapper goroutines and
+// evens out the work between them. If input has fewer than n entries, each gets a batch of its own.
+//
+// The returned inputs are keyed by batch number, and only master knows how to look inside them, so they must not be
+// given to map functions directly.
+func BatchInputs(input []MRInput, n int) []MRInput {
+	if n < 1 {
+		n = 1
+	}
+	if n > len(input) {
+		n = len(input)
+	}
+	if n == 0 {
+		return []MRInput{}
+	}
+
+	batches := make([]MRInput, 0, n)
+	size, extra := len(input)/n, len(input)%n
+	for i, start := 0, 0; i < n; i++ {
+		end := start + size
+		// The first extra batches take one of the leftover inputs each.
+		if i < extra {
+			end++
+		}
+		batches = append(batches, MRInput{Key: strconv.Itoa(i), batch: input[start:end]})
+		start = end
+	}
+	return batches
+}
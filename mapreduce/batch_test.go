@@ -0,0 +1,91 @@
+package mapreduce
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestBatchInputsSizes(t *testing.T) {
+	tests := []struct {
+		inputs, n int
+		sizes     []int
+	}{
+		{10, 3, []int{4, 3, 3}},
+		{9, 3, []int{3, 3, 3}},
+		{2, 5, []int{1, 1}},
+		{5, 0, []int{5}},
+		{0, 3, []int{}},
+	}
+
+	for _, test := range tests {
+		input := numberedInputs(test.inputs)
+		batches := BatchInputs(input, test.n)
+
+		sizes := []int{}
+		var flattened []MRInput
+		for _, batch := range batches {
+			sizes = append(sizes, len(batch.batch))
+			flattened = append(flattened, batch.batch...)
+		}
+		if !reflect.DeepEqual(test.sizes, sizes) {
+			t.Errorf("%d inputs in %d batches: Expected sizes <%v>; Got <%v>", test.inputs, test.n, test.sizes, sizes)
+		}
+		if len(input) > 0 && !reflect.DeepEqual(input, flattened) {
+			t.Errorf("%d inputs in %d batches: Expected every input once, in order; Got <%v>", test.inputs, test.n,
+				flattened)
+		}
+	}
+}
+
+func TestMapReduceBatchedInputs(t *testing.T) {
+	input := numberedInputs(100)
+	expected := MapReduce(input, wordMap, countReduce)
+
+	result, stats := MapReduceWithStats(BatchInputs(input, 7), wordMap, countReduce)
+
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+	if stats.MapTasks != 7 {
+		t.Errorf("Expected 7 map tasks; Got <%d>", stats.MapTasks)
+	}
+}
+
+func TestMapReduceBatchedInputsIsolated(t *testing.T) {
+	input := []MRInput{
+		{Key: "line1", Values: []string{"the dog"}},
+		{Key: "line2", Values: []string{"the cat"}},
+		{Key: "line3", Values: []string{"a bird"}},
+		{Key: "line4", Values: []string{"the fish"}},
+	}
+	// line2 panics partway through, and line3 signals done twice.
+	misbehavingMap := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		switch input.Key {
+		case "line2":
+			collectChl <- MRInput{Key: "the", Values: []string{"1"}}
+			panic("bad input")
+		case "line3":
+			wordMap(input, collectChl, doneChl)
+			doneChl <- struct{}{}
+		default:
+			wordMap(input, collectChl, doneChl)
+		}
+	}
+
+	out := run(context.Background(), BatchInputs(input, 1), []MapFunc{misbehavingMap}, countReduce, newConfig(nil))
+
+	var taskErr *TaskError
+	if !errors.As(out.err, &taskErr) || taskErr.Key != "line2" {
+		t.Errorf("Expected a *TaskError for line2; Got <%v>", out.err)
+	}
+	// The batch's other inputs all ran, and line2's output up to the panic is kept, as it would be unbatched.
+	expected := map[string][]string{"the": {"3"}, "dog": {"1"}, "a": {"1"}, "bird": {"1"}, "fish": {"1"}}
+	if !reflect.DeepEqual(expected, out.result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, out.result)
+	}
+	if out.stats.MapTasks != 1 {
+		t.Errorf("Expected 1 map task; Got <%d>", out.stats.MapTasks)
+	}
+}
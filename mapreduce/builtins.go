@@ -0,0 +1,313 @@
+package mapreduce
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"math/rand"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// This file holds ready-made map and reduce functions for common jobs.
+
+// IdentityMap re-emits its input unchanged. Paired with a reducer it makes a pure reduce (group-by) job.
+func IdentityMap(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+	collectChl <- input
+	doneChl <- struct{}{}
+}
+
+// WindowMap returns a mapper that emits each value of its input under the key of the window it belongs to, so that
+// the reducer aggregates per window. windowFunc is given the input's key with just the one value, and returns the
+// window's key, e.g. the start of the fixed-length interval holding a timestamp in the value. Each value goes into
+// exactly one window; for overlapping windows, emit each value under every window it belongs to from a mapper of
+// your own.
+func WindowMap(windowFunc func(MRInput) string) MapFunc {
+	return func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		for _, value := range input.Values {
+			window := windowFunc(MRInput{Key: input.Key, Values: []string{value}})
+			collectChl <- MRInput{Key: window, Values: []string{value}}
+		}
+		doneChl <- struct{}{}
+	}
+}
+
+// IdentityReduce re-emits its input, i.e. a key with all of its grouped values, unchanged. Paired with a mapper it
+// makes a pure map job whose result is the mapper output grouped by key.
+func IdentityReduce(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+	collectChl <- input
+	doneChl <- struct{}{}
+}
+
+// SumIntReduce emits the sum of a key's values, which must be integers such as those sent by EmitInt. If any value
+// doesn't parse, nothing is emitted for the key and the job fails with an error naming it.
+func SumIntReduce(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+	sum := 0
+	for _, value := range input.Values {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			emitError(collectChl, fmt.Errorf("mapreduce: summing key %q: %w", input.Key, err))
+			doneChl <- struct{}{}
+			return
+		}
+		sum += n
+	}
+	EmitInt(collectChl, input.Key, sum)
+	doneChl <- struct{}{}
+}
+
+// SumFloatReduce is the floating point counterpart of SumIntReduce, for values such as those sent by EmitFloat.
+func SumFloatReduce(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+	sum := 0.0
+	for _, value := range input.Values {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			emitError(collectChl, fmt.Errorf("mapreduce: summing key %q: %w", input.Key, err))
+			doneChl <- struct{}{}
+			return
+		}
+		sum += f
+	}
+	EmitFloat(collectChl, input.Key, sum)
+	doneChl <- struct{}{}
+}
+
+// CountReduce emits the number of values grouped under each key.
+func CountReduce(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+	EmitInt(collectChl, input.Key, len(input.Values))
+	doneChl <- struct{}{}
+}
+
+// ReservoirSampleReduce returns a reducer that emits a uniformly random sample of k of each key's values, or all of
+// them if the key has k or fewer. Each key is sampled with its own generator, seeded from seed and the key, so the
+// sample for a given key and list of values is the same from run to run; pass a varying seed, e.g. the time, for
+// different samples. Note that the order in which values reach a reducer varies between runs of a job with more than
+// one input, and so, then, does the sample. A k of 0 or less samples none of the values, emitting each key without
+// any.
+func ReservoirSampleReduce(k int, seed int64) ReduceFunc {
+	if k < 0 {
+		k = 0
+	}
+	return func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		h := fnv.New64a()
+		h.Write([]byte(input.Key))
+		rng := rand.New(rand.NewSource(seed ^ int64(h.Sum64())))
+
+		sample := make([]string, 0, k)
+		for i, value := range input.Values {
+			if i < k {
+				sample = append(sample, value)
+			} else if r := rng.Intn(i + 1); r < k {
+				sample[r] = value
+			}
+		}
+		collectChl <- MRInput{Key: input.Key, Values: sample}
+		doneChl <- struct{}{}
+	}
+}
+
+// HistogramReduce returns a reducer that counts how many of each key's values, which must be numbers, fall into each
+// of buckets, and emits the counts in bucket order. buckets are the buckets' inclusive upper bounds, in ascending
+// order, so a value goes into the first bucket whose bound is no less than it. One more count follows for the
+// overflow bucket, which holds the values greater than every bound. Like SumFloatReduce, it fails the job if a value
+// doesn't parse.
+func HistogramReduce(buckets []float64) ReduceFunc {
+	return func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		counts := make([]int, len(buckets)+1)
+		for _, value := range input.Values {
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				emitError(collectChl, fmt.Errorf("mapreduce: histogram of key %q: %w", input.Key, err))
+				doneChl <- struct{}{}
+				return
+			}
+			counts[sort.SearchFloat64s(buckets, f)]++
+		}
+
+		values := make([]string, len(counts))
+		for i, count := range counts {
+			values[i] = strconv.Itoa(count)
+		}
+		collectChl <- MRInput{Key: input.Key, Values: values}
+		doneChl <- struct{}{}
+	}
+}
+
+// PercentileReduce returns a reducer that emits the p-th percentile, for p between 0 and 100, of each key's values,
+// which must be numbers, such as the median (p=50) or p95 of a key's latencies. The values are sorted, and the
+// percentile is interpolated linearly between the two closest of them, so that a key with a single value emits that
+// value whatever p is. A key without values emits nothing. Like SumFloatReduce, it fails the job if a value doesn't
+// parse. A p outside 0 to 100 is taken to be the nearer of them; it panics if p is NaN.
+func PercentileReduce(p float64) ReduceFunc {
+	if math.IsNaN(p) {
+		panic("mapreduce: PercentileReduce needs a p that is a number")
+	}
+	p = math.Max(0, math.Min(p, 100))
+	return func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		if len(input.Values) == 0 {
+			doneChl <- struct{}{}
+			return
+		}
+		sorted := make([]float64, len(input.Values))
+		for i, value := range input.Values {
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				emitError(collectChl, fmt.Errorf("mapreduce: percentile of key %q: %w", input.Key, err))
+				doneChl <- struct{}{}
+				return
+			}
+			sorted[i] = f
+		}
+		sort.Float64s(sorted)
+
+		rank := p / 100 * float64(len(sorted)-1)
+		lower := int(rank)
+		percentile := sorted[lower]
+		if lower+1 < len(sorted) {
+			percentile += (rank - float64(lower)) * (sorted[lower+1] - sorted[lower])
+		}
+		EmitFloat(collectChl, input.Key, percentile)
+		doneChl <- struct{}{}
+	}
+}
+
+// ApproxDistinctReduce returns a reducer that emits an estimate of the number of distinct values of each key, using
+// a HyperLogLog sketch so that memory stays bounded however many values a key has. estimatedCardinality, a rough
+// guess at the largest count expected, sizes the sketch at between four and eight one-byte registers per expected
+// distinct value, from 16 to 2^16 registers. The typical relative error is 1.04 over the square root of the number of
+// registers: about 1.6% for an estimatedCardinality of a thousand, and 0.4% from about sixteen thousand up. Counts
+// that are small for the sketch are estimated by linear counting, which is close to exact.
+func ApproxDistinctReduce(estimatedCardinality int) ReduceFunc {
+	precision := bits.Len(uint(estimatedCardinality)) + 2
+	precision = int(math.Max(4, math.Min(float64(precision), 16)))
+	return func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		registers := make([]uint8, 1<<precision)
+		for _, value := range input.Values {
+			h := fnv.New64a()
+			h.Write([]byte(value))
+			hash := mix64(h.Sum64())
+			// The first precision bits pick the register, which keeps the longest run of leading zeros seen in the
+			// rest. The guard bit bounds the run for hashes whose remaining bits are all zero.
+			rest := hash<<precision | 1<<(precision-1)
+			register := hash >> (64 - precision)
+			if rank := uint8(bits.LeadingZeros64(rest) + 1); rank > registers[register] {
+				registers[register] = rank
+			}
+		}
+		EmitInt(collectChl, input.Key, int(math.Round(hyperLogLogEstimate(registers))))
+		doneChl <- struct{}{}
+	}
+}
+
+// hyperLogLogEstimate returns the number of distinct values estimated from the registers of a HyperLogLog sketch,
+// falling back on linear counting for small counts.
+func hyperLogLogEstimate(registers []uint8) float64 {
+	m := float64(len(registers))
+	var alpha float64
+	switch len(registers) {
+	case 16:
+		alpha = 0.673
+	case 32:
+		alpha = 0.697
+	case 64:
+		alpha = 0.709
+	default:
+		alpha = 0.7213 / (1 + 1.079/m)
+	}
+	sum, zeros := 0.0, 0
+	for _, r := range registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	estimate := alpha * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		return m * math.Log(m/float64(zeros))
+	}
+	return estimate
+}
+
+// mix64 scrambles the bits of a hash, as the finalizer of MurmurHash3 does, so that every bit of the result depends
+// on every bit of h. FNV-1a on its own leaves the high bits of the hashes of similar strings too alike.
+func mix64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// RankReduce emits each key's values ordered by descending weight, as sent by EmitWeighted. Values of equal weight,
+// including those without one, are in lexical order, so the ranking doesn't depend on the order the values arrived
+// in, which varies from run to run with how the mappers are scheduled.
+func RankReduce(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+	RankReduceBy(lexical)(input, collectChl, doneChl)
+}
+
+// RankReduceBy returns a reducer like RankReduce, but that breaks ties between values of equal weight with tieBreak,
+// which reports whether value a goes before value b. Values that tieBreak doesn't put in an order keep the order they
+// arrived in.
+func RankReduceBy(tieBreak func(a, b string) bool) ReduceFunc {
+	return func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		weights := input.Weights()
+		order := make([]int, len(input.Values))
+		for i := range order {
+			order[i] = i
+		}
+		weightOf := func(i int) float64 {
+			if i < len(weights) {
+				return weights[i]
+			}
+			return 0
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			if wa, wb := weightOf(order[a]), weightOf(order[b]); wa != wb {
+				return wa > wb
+			}
+			return tieBreak(input.Values[order[a]], input.Values[order[b]])
+		})
+
+		ranked := make([]string, len(order))
+		for i, o := range order {
+			ranked[i] = input.Values[o]
+		}
+		collectChl <- MRInput{Key: input.Key, Values: ranked}
+		doneChl <- struct{}{}
+	}
+}
+
+// DecayReduce returns a reducer that emits an exponentially time-decayed sum of each key's values, for trending
+// computations in which older contributions matter less. tsExtract returns the time of a value, and the value
+// contributes its weight, as sent by EmitWeighted, or 1 if the key's values came without weights, halved for every
+// halfLife of its age when the reducer runs. Values timestamped in the future count in full. It panics if halfLife
+// isn't positive.
+func DecayReduce(halfLife time.Duration, tsExtract func(value string) time.Time) ReduceFunc {
+	if halfLife <= 0 {
+		panic("mapreduce: DecayReduce needs a positive half-life")
+	}
+	return func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		now := input.now()
+		weights := input.Weights()
+		sum := 0.0
+		for i, value := range input.Values {
+			contribution := 1.0
+			if weights != nil {
+				contribution = 0
+				if i < len(weights) {
+					contribution = weights[i]
+				}
+			}
+			age := now.Sub(tsExtract(value))
+			if age < 0 {
+				age = 0
+			}
+			sum += contribution * math.Exp2(-float64(age)/float64(halfLife))
+		}
+		EmitFloat(collectChl, input.Key, sum)
+		doneChl <- struct{}{}
+	}
+}
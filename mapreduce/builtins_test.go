@@ -0,0 +1,346 @@
+package mapreduce
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdentityMapReduce(t *testing.T) {
+	input := []MRInput{
+		{Key: "a", Values: []string{"1", "2"}},
+		{Key: "b", Values: []string{"3"}},
+	}
+
+	result := MapReduce(input, IdentityMap, IdentityReduce)
+
+	expected := map[string][]string{"a": {"1", "2"}, "b": {"3"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
+
+func TestIdentityMapGroupsByKey(t *testing.T) {
+	input := []MRInput{
+		{Key: "a", Values: []string{"1"}},
+		{Key: "b", Values: []string{"2"}},
+		{Key: "a", Values: []string{"3"}},
+	}
+
+	result := MapReduce(input, IdentityMap, IdentityReduce)
+
+	sort.Strings(result["a"])
+	expected := map[string][]string{"a": {"1", "3"}, "b": {"2"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
+
+func TestCountReduce(t *testing.T) {
+	input := []MRInput{{Key: "line1", Values: []string{"the dog and the cat"}}}
+
+	result := MapReduce(input, wordMap, CountReduce)
+
+	expected := map[string][]string{"the": {"2"}, "dog": {"1"}, "and": {"1"}, "cat": {"1"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
+
+func TestReservoirSampleReduce(t *testing.T) {
+	values := []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}
+	input := []MRInput{{Key: "a", Values: values}, {Key: "b", Values: []string{"x", "y"}}}
+
+	first := MapReduce(input, IdentityMap, ReservoirSampleReduce(3, 42))
+	second := MapReduce(input, IdentityMap, ReservoirSampleReduce(3, 42))
+
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Expected the same samples for the same seed; Got <%v> and <%v>", first, second)
+	}
+	// A key with fewer values than the sample size keeps all of them.
+	expected := map[string][]string{"a": {"5", "4", "8"}, "b": {"x", "y"}}
+	if !reflect.DeepEqual(expected, first) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, first)
+	}
+}
+
+func TestReservoirSampleReduceEmpty(t *testing.T) {
+	input := []MRInput{{Key: "a", Values: []string{"0", "1", "2"}}}
+
+	for _, k := range []int{0, -1} {
+		result, err := TryMapReduce(input, IdentityMap, ReservoirSampleReduce(k, 42))
+
+		if err != nil {
+			t.Fatalf("Unexpected error <%v>", err)
+		}
+		if expected := map[string][]string{"a": {}}; !reflect.DeepEqual(expected, result) {
+			t.Errorf("Expected <%v>; Got <%v>", expected, result)
+		}
+	}
+}
+
+func TestHistogramReduce(t *testing.T) {
+	input := []MRInput{
+		{Key: "latency", Values: []string{"0.5", "1", "1.5", "7", "10", "10.1", "250", "-3"}},
+		{Key: "none"},
+	}
+
+	result, err := TryMapReduce(input, IdentityMap, HistogramReduce([]float64{1, 5, 10}))
+
+	if err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	// Buckets are (-inf, 1], (1, 5], (5, 10] and the overflow (10, inf).
+	expected := map[string][]string{"latency": {"3", "1", "2", "2"}, "none": {"0", "0", "0", "0"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+
+	input = []MRInput{{Key: "latency", Values: []string{"fast"}}}
+	if _, err := TryMapReduce(input, IdentityMap, HistogramReduce([]float64{1})); err == nil {
+		t.Errorf("Expected an error for a non-numeric value")
+	}
+}
+
+func TestPercentileReduce(t *testing.T) {
+	// 21 latencies, 0 to 200 in steps of 10, out of order.
+	var latencies []string
+	for i := 20; i >= 0; i-- {
+		latencies = append(latencies, strconv.Itoa(i*10))
+	}
+	input := []MRInput{
+		{Key: "latency", Values: latencies},
+		{Key: "single", Values: []string{"42"}},
+		{Key: "none"},
+		{Key: "pair", Values: []string{"1", "2"}},
+	}
+
+	median, err := TryMapReduce(input, IdentityMap, PercentileReduce(50))
+	if err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	p95, err := TryMapReduce(input, IdentityMap, PercentileReduce(95))
+	if err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+
+	expected := map[string][]string{"latency": {"100"}, "single": {"42"}, "pair": {"1.5"}}
+	if !reflect.DeepEqual(expected, median) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, median)
+	}
+	expected = map[string][]string{"latency": {"190"}, "single": {"42"}, "pair": {"1.95"}}
+	if !reflect.DeepEqual(expected, p95) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, p95)
+	}
+
+	input = []MRInput{{Key: "latency", Values: []string{"slow"}}}
+	if _, err := TryMapReduce(input, IdentityMap, PercentileReduce(50)); err == nil {
+		t.Errorf("Expected an error for a non-numeric value")
+	}
+}
+
+func TestPercentileReduceNaN(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected a panic for a p of NaN")
+		}
+	}()
+
+	PercentileReduce(math.NaN())
+}
+
+func TestApproxDistinctReduce(t *testing.T) {
+	// Each distinct value comes three times over.
+	valuesOf := func(distinct int) []string {
+		var values []string
+		for i := 0; i < distinct; i++ {
+			value := "user" + strconv.Itoa(i)
+			values = append(values, value, value, value)
+		}
+		return values
+	}
+	input := []MRInput{
+		{Key: "large", Values: valuesOf(100000)},
+		{Key: "medium", Values: valuesOf(5000)},
+		{Key: "small", Values: valuesOf(50)},
+		{Key: "empty", Values: valuesOf(0)},
+	}
+
+	result := MapReduce(input, IdentityMap, ApproxDistinctReduce(100000))
+
+	for key, distinct := range map[string]int{"large": 100000, "medium": 5000, "small": 50, "empty": 0} {
+		estimate, err := strconv.Atoi(result[key][0])
+		if err != nil {
+			t.Fatalf("Unexpected error <%v>", err)
+		}
+		if math.Abs(float64(estimate-distinct)) > 0.02*float64(distinct)+1 {
+			t.Errorf("Expected an estimate within 2%% of <%d> for %s; Got <%d>", distinct, key, estimate)
+		}
+	}
+}
+
+func TestWindowMap(t *testing.T) {
+	// Events are "<unix seconds> <name>", bucketed into one minute windows keyed by their start.
+	minute := func(event MRInput) string {
+		ts, _ := strconv.Atoi(strings.Fields(event.Values[0])[0])
+		return strconv.Itoa(ts - ts%60)
+	}
+	input := []MRInput{
+		{Key: "web1", Values: []string{"0 login", "59 click", "60 click"}},
+		{Key: "web2", Values: []string{"61 logout", "179 login", "30 click"}},
+	}
+
+	result := MapReduce(input, WindowMap(minute), CountReduce)
+
+	expected := map[string][]string{"0": {"3"}, "60": {"2"}, "120": {"1"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
+
+func TestRankReduce(t *testing.T) {
+	// Each input is "<query> <page> <score>".
+	mapFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		fields := strings.Fields(input.Values[0])
+		score, _ := strconv.ParseFloat(fields[2], 64)
+		EmitWeighted(collectChl, fields[0], fields[1], score)
+		doneChl <- struct{}{}
+	}
+	input := []MRInput{
+		{Key: "1", Values: []string{"dogs b.html 0.5"}},
+		{Key: "2", Values: []string{"dogs a.html 0.9"}},
+		{Key: "3", Values: []string{"cats c.html 0.1"}},
+		{Key: "4", Values: []string{"dogs c.html 0.7"}},
+		{Key: "5", Values: []string{"cats d.html 2"}},
+	}
+
+	result := MapReduce(input, mapFunc, RankReduce)
+
+	expected := map[string][]string{"dogs": {"a.html", "c.html", "b.html"}, "cats": {"d.html", "c.html"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
+
+func TestWeightsUnweightedValues(t *testing.T) {
+	mapFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		collectChl <- MRInput{Key: "k", Values: []string{"plain"}}
+		EmitWeighted(collectChl, "k", "heavy", 3)
+		collectChl <- MRInput{Key: "k", Values: []string{"plain2"}}
+		doneChl <- struct{}{}
+	}
+	var weights []float64
+	reduceFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		weights = input.Weights()
+		RankReduce(input, collectChl, doneChl)
+	}
+
+	result := MapReduce([]MRInput{{Key: "1"}}, mapFunc, reduceFunc)
+
+	if expected := []float64{0, 3, 0}; !reflect.DeepEqual(expected, weights) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, weights)
+	}
+	if expected := []string{"heavy", "plain", "plain2"}; !reflect.DeepEqual(expected, result["k"]) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result["k"])
+	}
+}
+
+func TestDecayReduce(t *testing.T) {
+	now := time.Now()
+	var input []MRInput
+	// A few recent mentions of "new" and many ten hours old of "old".
+	for i := 0; i < 3; i++ {
+		input = append(input, MRInput{Key: "new", Values: []string{now.Add(-time.Minute).Format(time.RFC3339Nano)}})
+	}
+	for i := 0; i < 10; i++ {
+		input = append(input, MRInput{Key: "old", Values: []string{now.Add(-10 * time.Hour).Format(time.RFC3339Nano)}})
+	}
+	tsExtract := func(value string) time.Time {
+		ts, _ := time.Parse(time.RFC3339Nano, value)
+		return ts
+	}
+
+	result := MapReduce(input, IdentityMap, DecayReduce(time.Hour, tsExtract))
+
+	recent, _ := strconv.ParseFloat(result["new"][0], 64)
+	old, _ := strconv.ParseFloat(result["old"][0], 64)
+	// The recent values are a sixtieth of a half-life old, and the old ones ten half-lives.
+	if recent < 2.9 || recent > 3 {
+		t.Errorf("Expected a score just under <3> for the recent values; Got <%v>", recent)
+	}
+	if old > 10.0/1024*1.01 || old < 10.0/1024*0.99 {
+		t.Errorf("Expected a score of about <%v> for the old values; Got <%v>", 10.0/1024, old)
+	}
+}
+
+func TestDecayReduceClock(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	ages := map[string]time.Duration{"now": 0, "hour": time.Hour, "twoHours": 2 * time.Hour, "future": -time.Hour}
+	var input []MRInput
+	for key, age := range ages {
+		input = append(input, MRInput{Key: key, Values: []string{clock.now.Add(-age).Format(time.RFC3339)}})
+	}
+	tsExtract := func(value string) time.Time {
+		ts, _ := time.Parse(time.RFC3339, value)
+		return ts
+	}
+
+	result := MapReduce(input, IdentityMap, DecayReduce(time.Hour, tsExtract), withClock(clock))
+
+	// The ages are by the job's clock, so each value is exactly as many half-lives old as its key says.
+	expected := map[string][]string{"now": {"1"}, "hour": {"0.5"}, "twoHours": {"0.25"}, "future": {"1"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
+
+func TestDecayReduceHalfLife(t *testing.T) {
+	for _, halfLife := range []time.Duration{0, -time.Hour} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("Expected a panic for a half-life of <%v>", halfLife)
+				}
+			}()
+			DecayReduce(halfLife, func(string) time.Time { return time.Time{} })
+		}()
+	}
+}
+
+func TestRankReduceTies(t *testing.T) {
+	// Pages spread over many mappers, most of them with the same score.
+	var input []MRInput
+	for i := 0; i < 50; i++ {
+		input = append(input, MRInput{Key: strconv.Itoa(i), Values: []string{fmt.Sprintf("page%02d", 49-i)}})
+	}
+	mapFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		weight := 1.0
+		if input.Values[0] == "page25" {
+			weight = 2
+		}
+		EmitWeighted(collectChl, "q", input.Values[0], weight)
+		doneChl <- struct{}{}
+	}
+
+	expected := []string{"page25"}
+	for i := 0; i < 50; i++ {
+		if i != 25 {
+			expected = append(expected, fmt.Sprintf("page%02d", i))
+		}
+	}
+	for i := 0; i < 10; i++ {
+		if result := MapReduce(input, mapFunc, RankReduce); !reflect.DeepEqual(expected, result["q"]) {
+			t.Fatalf("Expected <%v>; Got <%v>", expected, result["q"])
+		}
+	}
+
+	reversed := MapReduce(input, mapFunc, RankReduceBy(func(a, b string) bool { return a > b }))
+	if first, last := reversed["q"][1], reversed["q"][49]; first != "page49" || last != "page00" {
+		t.Errorf("Expected <page49> after the top value and <page00> last; Got <%v> and <%v>", first, last)
+	}
+}
@@ -0,0 +1,57 @@
+package mapreduce
+
+import (
+	"fmt"
+	"time"
+)
+
+// clock is the source of time for a job's timeouts, which tests replace with a fake one via withClock.
+type clock interface {
+	Now() time.Time
+	// After returns a channel that receives the time once d has elapsed, like time.After.
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the clock jobs use by default, which tells the actual time.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// timeTask runs task under the job's WithTaskTimeout, if it has one. The task gets channels of its own, and what it
+// sends on them is relayed to collectChl and doneChl until it times out, at which point an error is reported in its
+// place and everything else it sends is discarded.
+func (j *job) timeTask(phase, key string, collectChl chan MRInput, doneChl chan struct{},
+	task func(collectChl chan MRInput, doneChl chan struct{})) {
+	if j.cfg.taskTimeout <= 0 {
+		task(collectChl, doneChl)
+		return
+	}
+
+	timeout := j.cfg.clock.After(j.cfg.taskTimeout)
+	taskCollectChl, taskDoneChl := make(chan MRInput), make(chan struct{}, 1)
+	go task(taskCollectChl, taskDoneChl)
+	for {
+		select {
+		case kv := <-taskCollectChl:
+			collectChl <- kv
+		case <-taskDoneChl:
+			// The task's sends are unbuffered, so by the time it signals done everything it sent has been relayed, but
+			// for any it made from another goroutine as it signaled.
+			drainReady(taskCollectChl, func(kv MRInput) { collectChl <- kv })
+			doneChl <- struct{}{}
+			return
+		case <-timeout:
+			emitError(collectChl, fmt.Errorf("mapreduce: %s task on key %q didn't signal done within %v", phase, key,
+				j.cfg.taskTimeout))
+			doneChl <- struct{}{}
+			go drain(taskCollectChl, 1, taskDoneChl)
+			return
+		}
+	}
+}
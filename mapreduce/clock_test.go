@@ -0,0 +1,141 @@
+package mapreduce
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock that only moves when told to.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+	// waited is signalled whenever After is called.
+	waited sync.Cond
+}
+
+type fakeWaiter struct {
+	at time.Time
+	c  chan time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{at: c.now.Add(d), c: ch})
+	c.cond().Broadcast()
+	return ch
+}
+
+// BlockUntil waits until n calls of After are pending.
+func (c *fakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.waiters) < n {
+		c.cond().Wait()
+	}
+}
+
+// cond returns waited, ready for use. c.mu must be held.
+func (c *fakeClock) cond() *sync.Cond {
+	if c.waited.L == nil {
+		c.waited.L = &c.mu
+	}
+	return &c.waited
+}
+
+// Advance moves the clock on by d, firing the channels of every After that has then elapsed.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.c <- c.now
+	}
+	c.waiters = pending
+}
+
+func TestWithTaskTimeout(t *testing.T) {
+	clock := &fakeClock{}
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	reduceFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		if input.Key == "stuck" {
+			close(started)
+			<-release
+		}
+		countReduce(input, collectChl, doneChl)
+	}
+	// A single reduce goroutine means that no other reducer is running, and so at risk of timing out, while the
+	// stuck one is.
+	pool := NewPool(1)
+	defer pool.Close()
+	input := []MRInput{{Key: "line1", Values: []string{"the stuck dog"}}}
+
+	type outcome struct {
+		result map[string][]string
+		err    error
+	}
+	outcomeChl := make(chan outcome)
+	go func() {
+		result, err := TryMapReduce(input, wordMap, reduceFunc, WithTaskTimeout(time.Minute),
+			WithReduceExecutor(pool), withClock(clock))
+		outcomeChl <- outcome{result, err}
+	}()
+	<-started
+	clock.Advance(time.Minute)
+	out := <-outcomeChl
+
+	if out.err == nil || !strings.Contains(out.err.Error(), `reduce task on key "stuck"`) {
+		t.Errorf("Expected a timeout naming <stuck>; Got <%v>", out.err)
+	}
+	// The stuck reducer's output is missing, but the others ran once it had been abandoned.
+	expected := map[string][]string{"the": {"1"}, "dog": {"1"}}
+	if !reflect.DeepEqual(expected, out.result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, out.result)
+	}
+}
+
+func TestWithStallTimeout(t *testing.T) {
+	clock := &fakeClock{}
+	release := make(chan struct{})
+	defer close(release)
+	// Neither emits nor signals done until the test is over.
+	mapFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		<-release
+		doneChl <- struct{}{}
+	}
+
+	errChl := make(chan error)
+	go func() {
+		_, err := TryMapReduce(numberedInputs(1), mapFunc, countReduce, WithStallTimeout(time.Minute), withClock(clock))
+		errChl <- err
+	}()
+	// Wait for the collector to start waiting before letting the time pass.
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	err := <-errChl
+
+	if err == nil || !strings.Contains(err.Error(), "map phase stalled") {
+		t.Errorf("Expected the map phase to stall; Got <%v>", err)
+	}
+}
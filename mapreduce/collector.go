@@ -0,0 +1,146 @@
+package mapreduce
+
+import (
+	"context"
+	"sync"
+)
+
+// WithCollectorGoroutines has n goroutines, rather than one, receive what map and reduce functions send to the
+// collector. Each groups the plain records it receives into a map of its own, and the maps are merged once the phase
+// is over, so that on many cores a job whose functions emit a lot isn't held up by a single collector; see
+// Stats.EmitBlocks for whether it is. Records that the collector has to handle together with the rest of their key,
+// such as those sent with weights, Meta or indexes, and warnings and errors, are still handled by a single goroutine.
+// The result is the same as with one collector, but for the order of the values of a key, which the collector
+// doesn't guarantee in any case. A WithKeyNormalizer function must then be safe to call from several goroutines at
+// once. It has no effect with n less than 2, or together with options that need to see every record as it arrives:
+// WithSink, WithShuffler, WithMemoryPressureSpill, WithGroupKey, WithRecorder, WithKeyTTL, WithStallTimeout,
+// WithSequential, or a JobHandle.
+func WithCollectorGoroutines(n int) Option {
+	return func(cfg *config) {
+		cfg.collectors = n
+	}
+}
+
+// collectors returns the number of goroutines that collect the current phase's records, as WithCollectorGoroutines
+// allows.
+func (j *job) collectors() int {
+	cfg := j.cfg
+	if cfg.collectors < 2 || cfg.sink != nil || cfg.shuffler != nil || cfg.groupKeyFunc != nil ||
+		cfg.recorder != nil || cfg.keyTTL > 0 || cfg.stallTimeout > 0 || cfg.sequential || cfg.live != nil ||
+		cfg.memorySpill > 0 || cfg.collectStep != nil {
+		return 1
+	}
+	return cfg.collectors
+}
+
+// collectShard is the share of a phase's records that one of the goroutines of collectSharded collected.
+type collectShard struct {
+	results     map[string][]string
+	records     int
+	peakBacklog int
+}
+
+// collectSharded is collectResults for WithCollectorGoroutines: the plain records are grouped by
+// j.collectors() goroutines into shards, which add merges once every task is done or ctx is cancelled, and the
+// rest are passed to collectAll on the calling goroutine, as are all records for a single collector.
+func (j *job) collectSharded(ctx context.Context, collectChl chan MRInput, numProcs int, doneChl chan struct{},
+	collectAll func(result MRInput), add func(key string, result MRInput)) {
+	// central carries the records that have to be collected by the calling goroutine from the shards to it.
+	central := make(chan MRInput)
+	// stop is closed once every task is done, for the shards to collect what is still buffered and finish.
+	stop := make(chan struct{})
+	shards := make([]*collectShard, j.collectors())
+	var wg sync.WaitGroup
+	for i := range shards {
+		shard := &collectShard{results: make(map[string][]string)}
+		shards[i] = shard
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case result := <-collectChl:
+					j.collectToShard(ctx, shard, result, collectChl, central)
+				case <-stop:
+					// As in collectResults, output can still be waiting once every task is done.
+					drainReady(collectChl, func(result MRInput) {
+						j.collectToShard(ctx, shard, result, collectChl, central)
+					})
+					return
+				}
+			}
+		}()
+	}
+	shardsDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(shardsDone)
+	}()
+
+	for numProcs > 0 {
+		select {
+		case result := <-central:
+			collectAll(result)
+		case <-doneChl:
+			numProcs--
+		case <-ctx.Done():
+			go drain(collectChl, numProcs, doneChl)
+			numProcs = 0
+		}
+	}
+	close(stop)
+	for {
+		select {
+		case result := <-central:
+			if ctx.Err() == nil {
+				collectAll(result)
+			}
+			continue
+		case <-shardsDone:
+		}
+		break
+	}
+
+	for _, shard := range shards {
+		if j.phase == mapPhase {
+			j.stats.MapRecords += shard.records
+		} else {
+			j.stats.ReduceRecords += shard.records
+		}
+		if shard.peakBacklog > j.stats.PeakCollectBacklog {
+			j.stats.PeakCollectBacklog = shard.peakBacklog
+		}
+		for key, values := range shard.results {
+			add(key, MRInput{Values: values})
+		}
+	}
+}
+
+// collectToShard groups result, or each of the records in it if it is a batch, into shard, unless it has to go to
+// the calling goroutine of collectSharded on central. Nothing is collected once ctx is cancelled.
+func (j *job) collectToShard(ctx context.Context, shard *collectShard, result MRInput, collectChl chan MRInput,
+	central chan MRInput) {
+	if n := len(collectChl); n > shard.peakBacklog {
+		shard.peakBacklog = n
+	}
+	var collect func(result MRInput)
+	collect = func(result MRInput) {
+		for _, batchResult := range result.batch {
+			collect(batchResult)
+		}
+		switch {
+		case result.batch != nil || ctx.Err() != nil:
+		case result.ctl != nil || result.weights != nil || result.Meta != nil || result.valueMeta != nil ||
+			result.indexed:
+			central <- result
+		default:
+			shard.records++
+			key := j.intermediateKey(result)
+			if _, ok := shard.results[key]; !ok {
+				shard.results[key] = make([]string, 0, len(result.Values))
+			}
+			shard.results[key] = append(shard.results[key], result.Values...)
+		}
+	}
+	collect(result)
+}
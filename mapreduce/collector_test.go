@@ -0,0 +1,61 @@
+package mapreduce
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestWithCollectorGoroutines(t *testing.T) {
+	input := []MRInput{{Key: "1"}, {Key: "2"}, {Key: "3"}, {Key: "4"}}
+	expected, expectedStats := MapReduceWithStats(input, runsMap, SumIntReduce)
+
+	for _, n := range []int{1, 4, 8} {
+		result, stats := MapReduceWithStats(input, runsMap, SumIntReduce, WithCollectorGoroutines(n),
+			WithCollectBuffer(16))
+		if !reflect.DeepEqual(expected, result) {
+			t.Errorf("Expected <%v> with %d collectors; Got <%v>", expected, n, result)
+		}
+		if stats.MapRecords != expectedStats.MapRecords || stats.ReduceRecords != expectedStats.ReduceRecords {
+			t.Errorf("Expected <%d> and <%d> records with %d collectors; Got <%d> and <%d>",
+				expectedStats.MapRecords, expectedStats.ReduceRecords, n, stats.MapRecords, stats.ReduceRecords)
+		}
+	}
+}
+
+func TestWithCollectorGoroutinesCentralRecords(t *testing.T) {
+	// Weighted records, warnings and plain records of the same keys all end up in the result.
+	mapFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		i, _ := strconv.Atoi(input.Key)
+		EmitWeighted(collectChl, "q", "page"+input.Key, float64(i))
+		collectChl <- MRInput{Key: "q", Values: []string{"plain" + input.Key}}
+		Warn(collectChl, "seen "+input.Key)
+		doneChl <- struct{}{}
+	}
+	var input []MRInput
+	for i := 1; i <= 20; i++ {
+		input = append(input, MRInput{Key: fmt.Sprint(i)})
+	}
+
+	expected, expectedWarnings := MapReduceWithWarnings(input, mapFunc, RankReduce)
+	result, warnings := MapReduceWithWarnings(input, mapFunc, RankReduce, WithCollectorGoroutines(4))
+
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+	if len(expectedWarnings) != len(warnings) {
+		t.Errorf("Expected <%d> warnings; Got <%d>", len(expectedWarnings), len(warnings))
+	}
+}
+
+func BenchmarkCollectorGoroutines(b *testing.B) {
+	input := numberedInputs(8)
+	for _, n := range []int{1, 4, 8} {
+		b.Run(fmt.Sprintf("collectors=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				MapReduce(input, runsMap, SumIntReduce, WithCollectorGoroutines(n), WithCollectBuffer(64))
+			}
+		})
+	}
+}
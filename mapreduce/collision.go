@@ -0,0 +1,30 @@
+package mapreduce
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrKeyCollision is wrapped by the error reported, under WithDetectKeyCollision, when reducers for different keys
+// emit the same key.
+var ErrKeyCollision = errors.New("mapreduce: output key emitted by more than one reducer")
+
+// detectKeyCollisions wraps reduceFunc so that each output key is claimed by the first reducer to emit it, and any
+// other reducer emitting it reports an error.
+func (j *job) detectKeyCollisions(reduceFunc ReduceFunc) ReduceFunc {
+	var owners sync.Map
+	return func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		forward(reducePhase, reduceFunc, input, collectChl, func(kv MRInput) {
+			if kv.ctl != nil {
+				return
+			}
+			key := j.groupKey(kv.Key)
+			if owner, loaded := owners.LoadOrStore(key, input.Key); loaded && owner != input.Key {
+				emitError(collectChl, fmt.Errorf("%w: reducers for %q and %q both emitted %q", ErrKeyCollision,
+					owner, input.Key, key))
+			}
+		})
+		doneChl <- struct{}{}
+	}
+}
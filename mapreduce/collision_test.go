@@ -0,0 +1,49 @@
+package mapreduce
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// prefixReduce emits each key's count under the key's first letter, so that "dog" and "dingo" collide.
+func prefixReduce(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+	collectChl <- MRInput{Key: input.Key[:1], Values: []string{input.Key}}
+	doneChl <- struct{}{}
+}
+
+func TestDetectKeyCollision(t *testing.T) {
+	input := []MRInput{{Key: "line1", Values: []string{"dog dingo cat dog"}}}
+
+	result, err := TryMapReduce(input, wordMap, prefixReduce, WithDetectKeyCollision())
+
+	if !errors.Is(err, ErrKeyCollision) {
+		t.Fatalf("Expected <%v>; Got <%v>", ErrKeyCollision, err)
+	}
+	if !strings.Contains(err.Error(), `both emitted "d"`) {
+		t.Errorf("Expected the colliding key to be named; Got <%v>", err)
+	}
+	if len(result["c"]) != 1 || len(result["d"]) != 2 {
+		t.Errorf("Expected output to be collected regardless; Got <%v>", result)
+	}
+}
+
+func TestDetectKeyCollisionNone(t *testing.T) {
+	input := []MRInput{{Key: "line1", Values: []string{"dog cat dog"}}}
+
+	_, err := TryMapReduce(input, wordMap, prefixReduce, WithDetectKeyCollision())
+
+	if err != nil {
+		t.Errorf("Unexpected error <%v>", err)
+	}
+}
+
+func TestNoKeyCollisionDetectionByDefault(t *testing.T) {
+	input := []MRInput{{Key: "line1", Values: []string{"dog dingo"}}}
+
+	result, err := TryMapReduce(input, wordMap, prefixReduce)
+
+	if err != nil || len(result["d"]) != 2 {
+		t.Errorf("Expected values to be appended without error; Got <%v>, <%v>", result, err)
+	}
+}
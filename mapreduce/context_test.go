@@ -0,0 +1,176 @@
+package mapreduce
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCollectResultsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	collectChl := make(chan MRInput)
+	doneChl := make(chan struct{}, 1)
+	release := make(chan struct{})
+	go func() {
+		collectChl <- MRInput{Key: "a", Values: []string{"1"}}
+		collectChl <- MRInput{Key: "b", Values: []string{"2"}}
+		cancel()
+		// Stay "running" until the collector has given up, then finish so the drain can exit.
+		<-release
+		collectChl <- MRInput{Key: "c", Values: []string{"3"}}
+		doneChl <- struct{}{}
+	}()
+
+	j := &job{cfg: newConfig(nil)}
+	results := j.collectResults(ctx, collectChl, 1, doneChl)
+	close(release)
+
+	expected := map[string][]string{"a": {"1"}, "b": {"2"}}
+	if !reflect.DeepEqual(expected, results) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, results)
+	}
+}
+
+func TestMapReduceContextCancelledDuringMap(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	defer close(release)
+	mapFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		if input.Key == "slow" {
+			cancel()
+			<-release
+		}
+		wordMap(input, collectChl, doneChl)
+	}
+	var reduced int32
+	reduceFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		atomic.AddInt32(&reduced, 1)
+		countReduce(input, collectChl, doneChl)
+	}
+	input := []MRInput{{Key: "fast", Values: []string{"the dog"}}, {Key: "slow", Values: []string{"the cat"}}}
+
+	result, err := MapReduceContext(ctx, input, mapFunc, reduceFunc)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected <%v>; Got <%v>", context.Canceled, err)
+	}
+	if len(result) != 0 || atomic.LoadInt32(&reduced) != 0 {
+		t.Errorf("Expected no reducers to run; Got <%v> from <%d> reducers", result, reduced)
+	}
+}
+
+func TestMapReduceContextCancelledDuringReduce(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	defer close(release)
+	reduceFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		if input.Key == "dog" {
+			cancel()
+			<-release
+		}
+		countReduce(input, collectChl, doneChl)
+	}
+	input := []MRInput{{Key: "line1", Values: []string{"the dog"}}, {Key: "line2", Values: []string{"the cat"}}}
+
+	result, err := MapReduceContext(ctx, input, wordMap, reduceFunc)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected <%v>; Got <%v>", context.Canceled, err)
+	}
+	if _, ok := result["dog"]; ok {
+		t.Errorf("Expected the blocked reducer's output to be missing; Got <%v>", result)
+	}
+	complete := map[string][]string{"the": {"2"}, "cat": {"1"}}
+	for k, v := range result {
+		if !reflect.DeepEqual(complete[k], v) {
+			t.Errorf("Expected partial results to be a subset of <%v>; Got <%v>", complete, result)
+		}
+	}
+}
+
+func TestMapReduceContextNotCancelled(t *testing.T) {
+	input := []MRInput{{Key: "line1", Values: []string{"the dog"}}}
+
+	result, err := MapReduceContext(context.Background(), input, wordMap, countReduce)
+
+	if err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	expected := map[string][]string{"the": {"1"}, "dog": {"1"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
+
+func TestMapReduceContextCancelledCleanly(t *testing.T) {
+	input := numberedInputs(200)
+	complete := MapReduce(input, wordMap, countReduce)
+
+	// Each stage cancels the job at a different point. A worker that cancels it keeps emitting afterwards.
+	for stage, cancelAt := range map[string]struct {
+		mapKey, reduceKey string
+		barrier           bool
+	}{
+		"map":     {mapKey: "50"},
+		"shuffle": {barrier: true},
+		"reduce":  {reduceKey: "word000050"},
+	} {
+		before := runtime.NumGoroutine()
+		ctx, cancel := context.WithCancel(context.Background())
+		flood := func(collectChl chan MRInput) {
+			cancel()
+			for i := 0; i < 1000; i++ {
+				collectChl <- MRInput{Key: "flood", Values: []string{"1"}}
+			}
+		}
+		mapFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+			if input.Key == cancelAt.mapKey {
+				flood(collectChl)
+			}
+			wordMap(input, collectChl, doneChl)
+		}
+		reduceFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+			if input.Key == cancelAt.reduceKey {
+				flood(collectChl)
+			}
+			countReduce(input, collectChl, doneChl)
+		}
+		var opts []Option
+		if cancelAt.barrier {
+			opts = append(opts, WithBarrier(func(map[string][]string) { cancel() }))
+		}
+
+		result, err := MapReduceContext(ctx, input, mapFunc, reduceFunc, opts...)
+
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: Expected <%v>; Got <%v>", stage, context.Canceled, err)
+		}
+		if stage != "reduce" && len(result) != 0 {
+			t.Errorf("%s: Expected no results; Got <%v>", stage, result)
+		}
+		for k, v := range result {
+			if !reflect.DeepEqual(complete[k], v) {
+				t.Errorf("%s: Expected partial results to be a subset of <%v>; Got <%v: %v>", stage, complete, k, v)
+			}
+		}
+		waitForGoroutines(t, before)
+		cancel()
+	}
+}
+
+// waitForGoroutines fails t unless the number of goroutines falls back to before within a few seconds, as it does
+// once everything that a job started has exited.
+func waitForGoroutines(t *testing.T, before int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("Expected <%d> goroutines; Got <%d>", before, after)
+	}
+}
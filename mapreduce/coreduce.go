@@ -0,0 +1,48 @@
+package mapreduce
+
+import "context"
+
+// The tags that CoReduce prefixes values with to show which input they came from.
+const (
+	LeftTag  = "L:"
+	RightTag = "R:"
+)
+
+// CoReduce maps two datasets, each with a map function of its own, and reduces their outputs together by key, e.g.
+// to join them. Every value mapped from leftInput is prefixed with LeftTag, and every value mapped from rightInput
+// with RightTag, so the reducer can tell the two apart, say with strings.CutPrefix. Both map phases run as one, and
+// opts apply to the job as a whole.
+func CoReduce(leftInput []MRInput, leftMap MapFunc, rightInput []MRInput, rightMap MapFunc, reduceFunc ReduceFunc,
+	opts ...Option) (result map[string][]string) {
+	input := make([]MRInput, 0, len(leftInput)+len(rightInput))
+	for _, kv := range leftInput {
+		kv.mapFunc = tagMap(LeftTag, leftMap)
+		input = append(input, kv)
+	}
+	for _, kv := range rightInput {
+		kv.mapFunc = tagMap(RightTag, rightMap)
+		input = append(input, kv)
+	}
+
+	// Every input has a map function of its own, so the job's is never used.
+	out := run(context.TODO(), input, []MapFunc{IdentityMap}, reduceFunc, newConfig(opts))
+	out.mustSucceed()
+	return out.result
+}
+
+// tagMap returns a MapFunc that runs mapFunc, prefixing each value it emits with tag.
+func tagMap(tag string, mapFunc MapFunc) MapFunc {
+	return func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		intercept(mapPhase, mapFunc, input, func(kv MRInput) {
+			if kv.ctl == nil {
+				tagged := make([]string, len(kv.Values))
+				for i, value := range kv.Values {
+					tagged[i] = tag + value
+				}
+				kv.Values = tagged
+			}
+			collectChl <- kv
+		})
+		doneChl <- struct{}{}
+	}
+}
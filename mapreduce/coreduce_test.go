@@ -0,0 +1,59 @@
+package mapreduce
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// fieldsMap emits the first field of its input's only value as the key, and the rest as the value.
+func fieldsMap(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+	fields := strings.SplitN(input.Values[0], " ", 2)
+	collectChl <- MRInput{Key: fields[0], Values: []string{fields[1]}}
+	doneChl <- struct{}{}
+}
+
+func TestCoReduceInnerJoin(t *testing.T) {
+	users := []MRInput{
+		{Key: "1", Values: []string{"u1 alice"}},
+		{Key: "2", Values: []string{"u2 bob"}},
+		{Key: "3", Values: []string{"u3 carol"}},
+	}
+	orders := []MRInput{
+		{Key: "1", Values: []string{"u1 book"}},
+		{Key: "2", Values: []string{"u1 lamp"}},
+		{Key: "3", Values: []string{"u3 pen"}},
+		{Key: "4", Values: []string{"u4 mug"}},
+	}
+	// Emits a "<user> <item>" pair for each combination of the key's users and orders, and nothing for keys that
+	// only one side has.
+	joinReduce := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		var names, items []string
+		for _, value := range input.Values {
+			if name, ok := strings.CutPrefix(value, LeftTag); ok {
+				names = append(names, name)
+			} else if item, ok := strings.CutPrefix(value, RightTag); ok {
+				items = append(items, item)
+			}
+		}
+		var pairs []string
+		for _, name := range names {
+			for _, item := range items {
+				pairs = append(pairs, name+" "+item)
+			}
+		}
+		if len(pairs) > 0 {
+			sort.Strings(pairs)
+			collectChl <- MRInput{Key: input.Key, Values: pairs}
+		}
+		doneChl <- struct{}{}
+	}
+
+	result := CoReduce(users, fieldsMap, orders, fieldsMap, joinReduce)
+
+	expected := map[string][]string{"u1": {"alice book", "alice lamp"}, "u3": {"carol pen"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
@@ -0,0 +1,47 @@
+package mapreduce
+
+import "sort"
+
+// dedupResult removes, in place, the values of each key in result that equal considers a duplicate of an earlier one.
+func dedupResult(result map[string][]string, equal func(a, b string) bool) {
+	for key, values := range result {
+		result[key] = dedupValues(values, equal)
+	}
+}
+
+// dedupValues returns the values that aren't equal to any value before them, in order, reusing values' storage.
+func dedupValues(values []string, equal func(a, b string) bool) []string {
+	kept := values[:0]
+	for _, value := range values {
+		duplicate := false
+		for _, k := range kept {
+			if equal(k, value) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			kept = append(kept, value)
+		}
+	}
+	return kept
+}
+
+// addToSet adds values to set, returning those that weren't in it already, in order, each once.
+func addToSet(set map[string]bool, values []string) []string {
+	added := make([]string, 0, len(values))
+	for _, value := range values {
+		if !set[value] {
+			set[value] = true
+			added = append(added, value)
+		}
+	}
+	return added
+}
+
+// sortResultValues sorts the values of each key in result, in place.
+func sortResultValues(result map[string][]string) {
+	for _, values := range result {
+		sort.Strings(values)
+	}
+}
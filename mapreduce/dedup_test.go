@@ -0,0 +1,44 @@
+package mapreduce
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithValueDedup(t *testing.T) {
+	input := []MRInput{
+		{Key: "animals", Values: []string{"Dog", "cat", "dog", "CAT", "bird"}},
+		{Key: "cars", Values: []string{"Ford"}},
+	}
+
+	result := MapReduce(input, IdentityMap, IdentityReduce, WithValueDedup(strings.EqualFold))
+
+	expected := map[string][]string{"animals": {"Dog", "cat", "bird"}, "cars": {"Ford"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
+
+func TestWithValueSet(t *testing.T) {
+	var tags []string
+	for i := 0; i < 100; i++ {
+		tags = append(tags, "zebra", "émigré", "apple", "日本", "Zebra", "apple")
+	}
+	input := []MRInput{
+		{Key: "tags", Values: tags},
+		{Key: "more tags", Values: []string{"b", "a"}},
+		{Key: "more tags", Values: []string{"a", "c", "b"}},
+	}
+
+	result := MapReduce(input, IdentityMap, IdentityReduce, WithValueSet())
+
+	// Sorted by code point: upper case before lower case, and both before accented and CJK letters.
+	expected := map[string][]string{
+		"tags":      {"Zebra", "apple", "zebra", "émigré", "日本"},
+		"more tags": {"a", "b", "c"},
+	}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
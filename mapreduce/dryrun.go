@@ -0,0 +1,65 @@
+package mapreduce
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// dryRunTimeout is how long a dry run waits for each map function to signal done.
+var dryRunTimeout = 10 * time.Second
+
+// dryRun runs each of mapFuncs on the first of inputs, recording as errors of the job any panic or error they
+// report, any record they emit without a key, and any that doesn't signal done within dryRunTimeout. Warnings are
+// recorded as usual.
+func (j *job) dryRun(ctx context.Context, mapFuncs []MapFunc, inputs []MRInput) {
+	if len(inputs) == 0 {
+		return
+	}
+	input := inputs[0]
+	if input.batch != nil {
+		if len(input.batch) == 0 {
+			return
+		}
+		input = input.batch[0]
+	}
+
+	for i, mapFunc := range mapFuncs {
+		// A map function that times out is abandoned and may still emit, so guard what it emits to.
+		var mu sync.Mutex
+		var records []MRInput
+		done := make(chan struct{})
+		go func(mapFunc MapFunc) {
+			defer close(done)
+			intercept(mapPhase, mapFunc, input, func(kv MRInput) {
+				mu.Lock()
+				records = append(records, kv)
+				mu.Unlock()
+			})
+		}(mapFunc)
+
+		select {
+		case <-done:
+		case <-j.cfg.clock.After(dryRunTimeout):
+			j.errs = append(j.errs, fmt.Errorf("mapreduce: dry run: map function %d didn't signal done within %v on key %q",
+				i, dryRunTimeout, input.Key))
+		case <-ctx.Done():
+		}
+
+		mu.Lock()
+		for _, kv := range records {
+			switch {
+			case kv.ctl != nil:
+				j.handleControl(kv.ctl)
+			case kv.Key == "":
+				j.errs = append(j.errs, fmt.Errorf("mapreduce: dry run: map function %d emitted a record without a key "+
+					"on key %q", i, input.Key))
+			}
+		}
+		mu.Unlock()
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package mapreduce
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithDryRun(t *testing.T) {
+	var calls int32
+	mapFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		atomic.AddInt32(&calls, 1)
+		wordMap(input, collectChl, doneChl)
+	}
+
+	result, err := TryMapReduce(numberedInputs(100), mapFunc, countReduce, WithDryRun())
+
+	if err != nil {
+		t.Errorf("Unexpected error <%v>", err)
+	}
+	if len(result) != 0 {
+		t.Errorf("Expected an empty result; Got <%v>", result)
+	}
+	if calls != 1 {
+		t.Errorf("Expected the mapper to run once; Got <%d>", calls)
+	}
+}
+
+func TestWithDryRunBrokenMappers(t *testing.T) {
+	input := []MRInput{{Key: "line1", Values: []string{"the boom"}}}
+	noKeyMap := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		collectChl <- MRInput{Values: []string{"1"}}
+		doneChl <- struct{}{}
+	}
+
+	_, err := TryMapReduce(input, panickyWordMap, countReduce, WithDryRun())
+	var taskErr *TaskError
+	if !errors.As(err, &taskErr) {
+		t.Errorf("Expected a *TaskError; Got <%v>", err)
+	}
+
+	_, err = TryMapReduce(input, noKeyMap, countReduce, WithDryRun())
+	if err == nil || !strings.Contains(err.Error(), "without a key") {
+		t.Errorf("Expected an error about the missing key; Got <%v>", err)
+	}
+}
+
+func TestWithDryRunHungMapper(t *testing.T) {
+	defer func(timeout time.Duration) { dryRunTimeout = timeout }(dryRunTimeout)
+	dryRunTimeout = 10 * time.Millisecond
+	release := make(chan struct{})
+	defer close(release)
+	hungMap := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		<-release
+		doneChl <- struct{}{}
+	}
+
+	_, err := TryMapReduce(numberedInputs(1), hungMap, countReduce, WithDryRun())
+
+	if err == nil || !strings.Contains(err.Error(), "didn't signal done") {
+		t.Errorf("Expected an error about the missing done signal; Got <%v>", err)
+	}
+}
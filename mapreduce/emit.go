@@ -0,0 +1,37 @@
+package mapreduce
+
+import (
+	"strconv"
+	"strings"
+)
+
+// EmitInt sends key with n, formatted in base 10, as its value.
+func EmitInt(collectChl chan MRInput, key string, n int) {
+	collectChl <- MRInput{Key: key, Values: []string{strconv.Itoa(n)}}
+}
+
+// EmitFloat sends key with f as its value, formatted with the fewest digits that parse back to exactly f.
+func EmitFloat(collectChl chan MRInput, key string, f float64) {
+	collectChl <- MRInput{Key: key, Values: []string{strconv.FormatFloat(f, 'g', -1, 64)}}
+}
+
+// EmitWeighted sends key with value, along with a weight for reducers to order or aggregate values by without having
+// to encode it in the value, such as a score for RankReduce. The weight is only available to reducers, through their
+// input's Weights method. Options that drop values before the reduce phase, or reorder them, don't keep the weights
+// in step, so they shouldn't be combined with weighted values.
+func EmitWeighted(collectChl chan MRInput, key, value string, weight float64) {
+	collectChl <- MRInput{Key: key, Values: []string{value}, weights: []float64{weight}}
+}
+
+// EmitSplit splits raw around each occurrence of sep and sends key with the fields as its values, in order. Every
+// separator delimits a field, so empty fields, including one after a trailing separator, are sent as empty values
+// and a value's position always matches its field's; an empty raw is a single empty field. If sep is empty, raw is
+// split after each UTF-8 sequence, as by strings.Split.
+func EmitSplit(collectChl chan MRInput, key, raw, sep string) {
+	collectChl <- MRInput{Key: key, Values: strings.Split(raw, sep)}
+}
+
+// emitError reports err to the collector, making it one of the job's errors.
+func emitError(collectChl chan MRInput, err error) {
+	collectChl <- MRInput{ctl: &control{err: err}}
+}
@@ -0,0 +1,99 @@
+package mapreduce
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestEmitIntRoundTrip(t *testing.T) {
+	input := []MRInput{
+		{Key: "a", Values: []string{"3"}},
+		{Key: "b", Values: []string{"-4"}},
+		{Key: "c", Values: []string{"5"}},
+	}
+	// Emits every input's number under "total", and the odd ones under "odd".
+	mapFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		n, _ := strconv.Atoi(input.Values[0])
+		EmitInt(collectChl, "total", n)
+		if n%2 != 0 {
+			EmitInt(collectChl, "odd", n)
+		}
+		doneChl <- struct{}{}
+	}
+
+	result, err := TryMapReduce(input, mapFunc, SumIntReduce)
+
+	if err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	expected := map[string][]string{"total": {"4"}, "odd": {"8"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
+
+func TestEmitFloatRoundTrip(t *testing.T) {
+	input := []MRInput{{Key: "a", Values: []string{"0.1"}}, {Key: "b", Values: []string{"0.25"}}}
+	mapFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		f, _ := strconv.ParseFloat(input.Values[0], 64)
+		EmitFloat(collectChl, "total", f)
+		doneChl <- struct{}{}
+	}
+
+	result, err := TryMapReduce(input, mapFunc, SumFloatReduce)
+
+	if err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	if !reflect.DeepEqual(map[string][]string{"total": {"0.35"}}, result) {
+		t.Errorf("Expected <map[total:[0.35]]>; Got <%v>", result)
+	}
+}
+
+func TestEmitSplit(t *testing.T) {
+	input := []MRInput{
+		{Key: "row1", Values: []string{"alice,30,paris"}},
+		{Key: "row2", Values: []string{"bob,,"}},
+		{Key: "row3", Values: []string{",40,rome,"}},
+		{Key: "row4", Values: []string{""}},
+	}
+	// Emits each row's fields under the row's key.
+	mapFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		EmitSplit(collectChl, input.Key, input.Values[0], ",")
+		doneChl <- struct{}{}
+	}
+
+	result := MapReduce(input, mapFunc, IdentityReduce)
+
+	expected := map[string][]string{
+		"row1": {"alice", "30", "paris"},
+		"row2": {"bob", "", ""},
+		"row3": {"", "40", "rome", ""},
+		"row4": {""},
+	}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
+
+func TestSumReduceBadValues(t *testing.T) {
+	input := []MRInput{
+		{Key: "good", Values: []string{"1", "2"}},
+		{Key: "bad", Values: []string{"1", "two"}},
+	}
+
+	for name, reduceFunc := range map[string]ReduceFunc{"int": SumIntReduce, "float": SumFloatReduce} {
+		result, err := TryMapReduce(input, IdentityMap, reduceFunc)
+
+		var numErr *strconv.NumError
+		if !errors.As(err, &numErr) || !strings.Contains(err.Error(), `"bad"`) {
+			t.Errorf("%s: Expected a parse error naming <bad>; Got <%v>", name, err)
+		}
+		if !reflect.DeepEqual(map[string][]string{"good": {"3"}}, result) {
+			t.Errorf("%s: Expected <map[good:[3]]>; Got <%v>", name, result)
+		}
+	}
+}
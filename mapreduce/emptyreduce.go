@@ -0,0 +1,32 @@
+package mapreduce
+
+// EmptyReducePolicy determines what a job's result holds for a key whose reducer emitted nothing under it.
+type EmptyReducePolicy struct {
+	keep     bool
+	sentinel []string
+}
+
+var (
+	// DropKey leaves the key out of the result, which lets a reducer filter its key out by emitting nothing. It is
+	// the default.
+	DropKey = EmptyReducePolicy{}
+	// KeepEmpty keeps the key in the result, with no values.
+	KeepEmpty = EmptyReducePolicy{keep: true}
+)
+
+// Sentinel returns a policy that keeps the key in the result, with value as its only value.
+func Sentinel(value string) EmptyReducePolicy {
+	return EmptyReducePolicy{keep: true, sentinel: []string{value}}
+}
+
+// fillEmpty adds to result each of the keys of intermediate that result doesn't have, as the policy calls for.
+func (p EmptyReducePolicy) fillEmpty(intermediate, result map[string][]string) {
+	if !p.keep {
+		return
+	}
+	for key := range intermediate {
+		if _, ok := result[key]; !ok {
+			result[key] = append(make([]string, 0, len(p.sentinel)), p.sentinel...)
+		}
+	}
+}
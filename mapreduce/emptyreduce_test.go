@@ -0,0 +1,39 @@
+package mapreduce
+
+import (
+	"reflect"
+	"testing"
+)
+
+// dropDogReduce counts every key's values, except for "dog", for which it emits nothing.
+func dropDogReduce(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+	if input.Key == "dog" {
+		doneChl <- struct{}{}
+		return
+	}
+	countReduce(input, collectChl, doneChl)
+}
+
+func TestEmptyReducePolicies(t *testing.T) {
+	input := []MRInput{{Key: "line1", Values: []string{"the dog and the cat"}}}
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected map[string][]string
+	}{
+		{"default", nil, map[string][]string{"the": {"2"}, "and": {"1"}, "cat": {"1"}}},
+		{"DropKey", []Option{WithEmptyReducePolicy(DropKey)}, map[string][]string{"the": {"2"}, "and": {"1"},
+			"cat": {"1"}}},
+		{"KeepEmpty", []Option{WithEmptyReducePolicy(KeepEmpty)}, map[string][]string{"the": {"2"}, "and": {"1"},
+			"cat": {"1"}, "dog": {}}},
+		{"Sentinel", []Option{WithEmptyReducePolicy(Sentinel("n/a"))}, map[string][]string{"the": {"2"},
+			"and": {"1"}, "cat": {"1"}, "dog": {"n/a"}}},
+	}
+	for _, test := range tests {
+		result := MapReduce(input, wordMap, dropDogReduce, test.opts...)
+
+		if !reflect.DeepEqual(test.expected, result) {
+			t.Errorf("%s: Expected <%v>; Got <%v>", test.name, test.expected, result)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package mapreduce
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ResultEncoder writes the keys of a result in some format, a key and its values at a time; see WriteResult.
+// JSONLinesEncoder, CSVEncoder and TSVEncoder are the formats that come with the package, each with a function to
+// read it back: DecodeJSONLines, DecodeCSV and DecodeTSV.
+type ResultEncoder interface {
+	// Encode writes key and its values to w.
+	Encode(w io.Writer, key string, values []string) error
+}
+
+// WriteResult writes each key of result and its values to w with enc, in key order, so that the same result is
+// always written the same way. It stops at the first key that enc fails to write, returning the error.
+func WriteResult(w io.Writer, result map[string][]string, enc ResultEncoder) error {
+	keys := make([]string, 0, len(result))
+	for key := range result {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := enc.Encode(w, key, result[key]); err != nil {
+			return fmt.Errorf("mapreduce: writing key %q: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// JSONLinesEncoder writes each key as a line holding the JSON encoding of an MRInput, such as
+// {"Key":"k","Values":["v"]}, the format DecodeJSONLines reads.
+type JSONLinesEncoder struct{}
+
+// Encode implements ResultEncoder.
+func (JSONLinesEncoder) Encode(w io.Writer, key string, values []string) error {
+	if values == nil {
+		// A key without values is still written with a list of them, as it appears in a result.
+		values = []string{}
+	}
+	return json.NewEncoder(w).Encode(struct {
+		Key    string
+		Values []string
+	}{key, values})
+}
+
+// CSVEncoder writes each key as a CSV record, as RFC 4180 describes, with the key as its first field and the values
+// as the rest. Fields are quoted as needed, so keys and values may hold commas, quotes and line breaks, though a \r\n
+// in one is read back by DecodeCSV as \n, as encoding/csv reads it; TSVEncoder and JSONLinesEncoder keep \r\n. An
+// empty key without values is written as "", as a blank line would be skipped when it is read.
+type CSVEncoder struct{}
+
+// Encode implements ResultEncoder.
+func (CSVEncoder) Encode(w io.Writer, key string, values []string) error {
+	if key == "" && len(values) == 0 {
+		_, err := io.WriteString(w, "\"\"\n")
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append([]string{key}, values...)); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// DecodeCSV reads the records written by CSVEncoder from r, returning an MRInput for each, in order. A \r\n within a
+// quoted field is read as \n.
+func DecodeCSV(r io.Reader) ([]MRInput, error) {
+	cr := csv.NewReader(r)
+	// Keys have as many values as they have.
+	cr.FieldsPerRecord = -1
+	var inputs []MRInput
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return inputs, nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return nil, &LineError{Line: parseErr.Line, Err: err}
+			}
+			return nil, fmt.Errorf("mapreduce: reading CSV: %w", err)
+		}
+		inputs = append(inputs, MRInput{Key: record[0], Values: record[1:]})
+	}
+}
+
+// TSVEncoder writes each key as a line of tab-separated fields, the key first and then the values. Tabs, line
+// breaks and backslashes in them are escaped as \t, \n, \r and \\, so that every key takes exactly one line. An empty
+// key without values takes an empty line, which DecodeTSV reads back as such rather than skipping.
+type TSVEncoder struct{}
+
+// tsvEscaper and tsvUnescaper escape and unescape the fields written by TSVEncoder.
+var (
+	tsvEscaper   = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+	tsvUnescaper = strings.NewReplacer(`\\`, `\`, `\t`, "\t", `\n`, "\n", `\r`, "\r")
+)
+
+// Encode implements ResultEncoder.
+func (TSVEncoder) Encode(w io.Writer, key string, values []string) error {
+	var b strings.Builder
+	b.WriteString(tsvEscaper.Replace(key))
+	for _, value := range values {
+		b.WriteByte('\t')
+		b.WriteString(tsvEscaper.Replace(value))
+	}
+	b.WriteByte('\n')
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// DecodeTSV reads the lines written by TSVEncoder from r, returning an MRInput for each, in order.
+func DecodeTSV(r io.Reader) ([]MRInput, error) {
+	var inputs []MRInput
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<30)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		for i, field := range fields {
+			fields[i] = tsvUnescaper.Replace(field)
+		}
+		inputs = append(inputs, MRInput{Key: fields[0], Values: fields[1:]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("mapreduce: reading TSV: %w", err)
+	}
+	return inputs, nil
+}
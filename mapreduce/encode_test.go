@@ -0,0 +1,122 @@
+package mapreduce
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// encodedResult is a result whose keys and values hold the characters that the formats have to quote or escape.
+var encodedResult = map[string][]string{
+	"plain":          {"a", "b"},
+	"with, comma":    {`say "hi"`, "x,y"},
+	"tab\tand\nnl":   {"back\\slash", `\t is not a tab`, "cr\rlf"},
+	"no values":      {},
+	"":               {},
+	"empty":          {""},
+	`"quoted key"`:   {"line one\nline two"},
+	"crlf":           {"line one\r\nline two"},
+	"unicode ключ":   {"värde"},
+	"trailing tab\t": {"\t"},
+}
+
+// decodedResult groups inputs into a result, keeping each key's values in order.
+func decodedResult(t *testing.T, inputs []MRInput) map[string][]string {
+	t.Helper()
+	result := make(map[string][]string)
+	for _, input := range inputs {
+		if _, ok := result[input.Key]; ok {
+			t.Errorf("Expected key <%q> once; Got it again", input.Key)
+		}
+		result[input.Key] = input.Values
+	}
+	return result
+}
+
+func TestWriteResultJSONLines(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteResult(&b, encodedResult, JSONLinesEncoder{}); err != nil {
+		t.Fatalf("Expected no error; Got <%v>", err)
+	}
+	expected := `{"Key":"","Values":[]}`
+	if first := strings.SplitN(b.String(), "\n", 2)[0]; first != expected {
+		t.Errorf("Expected <%s> first, in key order; Got <%s>", expected, first)
+	}
+
+	inputCh, wait := DecodeJSONLines(&b, 1)
+	var inputs []MRInput
+	for input := range inputCh {
+		inputs = append(inputs, input)
+	}
+	if err := wait(); err != nil {
+		t.Fatalf("Expected no error; Got <%v>", err)
+	}
+	if decoded := decodedResult(t, inputs); !reflect.DeepEqual(encodedResult, decoded) {
+		t.Errorf("Expected <%q>; Got <%q>", encodedResult, decoded)
+	}
+}
+
+func TestWriteResultCSV(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteResult(&b, encodedResult, CSVEncoder{}); err != nil {
+		t.Fatalf("Expected no error; Got <%v>", err)
+	}
+
+	inputs, err := DecodeCSV(&b)
+	if err != nil {
+		t.Fatalf("Expected no error; Got <%v>", err)
+	}
+	// Reading CSV turns a quoted \r\n into \n, as any other line break is.
+	expected := make(map[string][]string, len(encodedResult))
+	for key, values := range encodedResult {
+		expected[key] = make([]string, len(values))
+		for i, value := range values {
+			expected[key][i] = strings.ReplaceAll(value, "\r\n", "\n")
+		}
+	}
+	if decoded := decodedResult(t, inputs); !reflect.DeepEqual(expected, decoded) {
+		t.Errorf("Expected <%q>; Got <%q>", expected, decoded)
+	}
+
+	if _, err := DecodeCSV(strings.NewReader("ok,1\n\"unterminated,2\n")); err == nil {
+		t.Errorf("Expected an error for malformed CSV")
+	}
+}
+
+func TestWriteResultTSV(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteResult(&b, encodedResult, TSVEncoder{}); err != nil {
+		t.Fatalf("Expected no error; Got <%v>", err)
+	}
+	if lines := strings.Count(b.String(), "\n"); lines != len(encodedResult) {
+		t.Errorf("Expected <%d> lines; Got <%d>", len(encodedResult), lines)
+	}
+
+	inputs, err := DecodeTSV(&b)
+	if err != nil {
+		t.Fatalf("Expected no error; Got <%v>", err)
+	}
+	if decoded := decodedResult(t, inputs); !reflect.DeepEqual(encodedResult, decoded) {
+		t.Errorf("Expected <%q>; Got <%q>", encodedResult, decoded)
+	}
+}
+
+// failingEncoder is a ResultEncoder that fails on one key.
+type failingEncoder struct{ key string }
+
+func (e failingEncoder) Encode(w io.Writer, key string, values []string) error {
+	if key == e.key {
+		return errors.New("no room")
+	}
+	return nil
+}
+
+func TestWriteResultError(t *testing.T) {
+	err := WriteResult(io.Discard, encodedResult, failingEncoder{key: "plain"})
+	if err == nil || !strings.Contains(err.Error(), `"plain"`) {
+		t.Errorf("Expected an error naming key <plain>; Got <%v>", err)
+	}
+}
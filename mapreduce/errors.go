@@ -0,0 +1,48 @@
+package mapreduce
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Names of the phases of a job, as reported in a TaskError.
+const (
+	mapPhase    = "map"
+	reducePhase = "reduce"
+)
+
+// PanicPolicy determines how a job responds to a map or reduce function that panics.
+type PanicPolicy int
+
+const (
+	// PanicError records the panic as a *TaskError and lets the rest of the job run to completion, so that the job
+	// fails with whatever the other tasks produced. It is the default.
+	PanicError PanicPolicy = iota
+	// PanicFail fails the job as soon as the panic reaches the collector, cancelling the tasks still to run. The
+	// job's result is then empty if the panic happened in the map phase, and partial if it happened in the reduce
+	// phase.
+	PanicFail
+	// PanicSkip reports the panic as a warning, and the job succeeds without whatever the task would have emitted
+	// after panicking. To discard a failed map function's output altogether, use WithSkipFailedInputs.
+	PanicSkip
+)
+
+// TaskError reports a map or reduce function that panicked.
+type TaskError struct {
+	// Phase is "map" or "reduce".
+	Phase string
+	// Key is the key of the MRInput the function was processing.
+	Key string
+	// Value is the value the function panicked with.
+	Value interface{}
+}
+
+func (e *TaskError) Error() string {
+	return fmt.Sprintf("mapreduce: %s function panicked on key %q: %v", e.Phase, e.Key, e.Value)
+}
+
+// isPanic reports whether err is a *TaskError.
+func isPanic(err error) bool {
+	var taskErr *TaskError
+	return errors.As(err, &taskErr)
+}
@@ -0,0 +1,238 @@
+package mapreduce
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// panickyWordMap behaves like wordMap, except that it panics on inputs containing the word "boom".
+func panickyWordMap(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+	if strings.Contains(input.Values[0], "boom") {
+		panic("found a boom")
+	}
+	wordMap(input, collectChl, doneChl)
+}
+
+func TestTryMapReducePanickingMapper(t *testing.T) {
+	input := []MRInput{
+		{Key: "line1", Values: []string{"the dog"}},
+		{Key: "line2", Values: []string{"the boom"}},
+		{Key: "line3", Values: []string{"the cat"}},
+	}
+
+	result, err := TryMapReduce(input, panickyWordMap, countReduce)
+
+	var taskErr *TaskError
+	if !errors.As(err, &taskErr) {
+		t.Fatalf("Expected a *TaskError; Got <%v>", err)
+	}
+	if taskErr.Phase != "map" || taskErr.Key != "line2" || taskErr.Value != "found a boom" {
+		t.Errorf("Expected the map of <line2> to be reported; Got <%+v>", taskErr)
+	}
+	if !strings.Contains(err.Error(), `"line2"`) {
+		t.Errorf("Expected error to name the offending key; Got <%v>", err)
+	}
+	expected := map[string][]string{"the": {"2"}, "dog": {"1"}, "cat": {"1"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected the other inputs' results <%v>; Got <%v>", expected, result)
+	}
+}
+
+func TestTryMapReducePanickingReducer(t *testing.T) {
+	input := []MRInput{{Key: "line1", Values: []string{"the dog"}}}
+	reduceFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		if input.Key == "dog" {
+			panic(errors.New("no dogs allowed"))
+		}
+		countReduce(input, collectChl, doneChl)
+	}
+
+	result, err := TryMapReduce(input, wordMap, reduceFunc)
+
+	var taskErr *TaskError
+	if !errors.As(err, &taskErr) || taskErr.Phase != "reduce" || taskErr.Key != "dog" {
+		t.Fatalf("Expected the reduce of <dog> to be reported; Got <%v>", err)
+	}
+	if !reflect.DeepEqual(map[string][]string{"the": {"1"}}, result) {
+		t.Errorf("Expected <map[the:[1]]>; Got <%v>", result)
+	}
+}
+
+func TestMapReducePanicsWithTaskError(t *testing.T) {
+	defer func() {
+		r := recover()
+		taskErr, ok := r.(error)
+		if !ok || !strings.Contains(taskErr.Error(), `"line1"`) {
+			t.Errorf("Expected a panic naming <line1>; Got <%v>", r)
+		}
+	}()
+
+	MapReduce([]MRInput{{Key: "line1", Values: []string{"boom"}}}, panickyWordMap, countReduce)
+}
+
+func TestTryMapReducePanicAfterDone(t *testing.T) {
+	panicked := make(chan struct{})
+	reduceFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		if input.Key == "bad" {
+			doneChl <- struct{}{}
+			close(panicked)
+			panic("after done")
+		}
+		// The other reducer is still running once the bad one has panicked, and mustn't be taken for done.
+		<-panicked
+		time.Sleep(10 * time.Millisecond)
+		countReduce(input, collectChl, doneChl)
+	}
+	input := []MRInput{{Key: "line1", Values: []string{"bad slow"}}}
+
+	result, err := TryMapReduce(input, wordMap, reduceFunc)
+
+	var taskErr *TaskError
+	if !errors.As(err, &taskErr) || taskErr.Key != "bad" {
+		t.Errorf("Expected a *TaskError for bad; Got <%v>", err)
+	}
+	expected := map[string][]string{"slow": {"1"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
+
+func TestSkipFailedInputs(t *testing.T) {
+	// The failing input emits a word before panicking, which must not reach the result.
+	failingMap := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		if strings.Contains(input.Values[0], "boom") {
+			collectChl <- MRInput{Key: "partial", Values: []string{"1"}}
+		}
+		panickyWordMap(input, collectChl, doneChl)
+	}
+	input := []MRInput{
+		{Key: "line1", Values: []string{"the dog"}},
+		{Key: "line2", Values: []string{"the boom"}},
+		{Key: "line3", Values: []string{"the cat"}},
+	}
+
+	for _, in := range [][]MRInput{input, BatchInputs(input, 2)} {
+		result, stats := MapReduceWithStats(in, failingMap, countReduce, WithSkipFailedInputs())
+
+		expected := map[string][]string{"the": {"2"}, "dog": {"1"}, "cat": {"1"}}
+		if !reflect.DeepEqual(expected, result) {
+			t.Errorf("Expected <%v>; Got <%v>", expected, result)
+		}
+		expectedFailed := []MRInput{input[1]}
+		if !reflect.DeepEqual(expectedFailed, stats.FailedInputs) {
+			t.Errorf("Expected <%v>; Got <%v>", expectedFailed, stats.FailedInputs)
+		}
+	}
+}
+
+func TestPanicPolicyError(t *testing.T) {
+	input := []MRInput{
+		{Key: "line1", Values: []string{"the dog"}},
+		{Key: "line2", Values: []string{"the boom"}},
+	}
+
+	result, err := TryMapReduce(input, panickyWordMap, countReduce, WithPanicPolicy(PanicError))
+
+	var taskErr *TaskError
+	if !errors.As(err, &taskErr) {
+		t.Errorf("Expected a *TaskError; Got <%v>", err)
+	}
+	expected := map[string][]string{"the": {"1"}, "dog": {"1"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
+
+func TestPanicPolicyFail(t *testing.T) {
+	var mu sync.Mutex
+	mapped := 0
+	// The first input panics, and the pool runs one mapper at a time, so few if any others get to run.
+	mapFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		if input.Key == "0" {
+			panic("found a boom")
+		}
+		mu.Lock()
+		mapped++
+		mu.Unlock()
+		wordMap(input, collectChl, doneChl)
+	}
+	pool := NewPool(1)
+	defer pool.Close()
+
+	result, err := TryMapReduce(numberedInputs(100), mapFunc, countReduce, WithPanicPolicy(PanicFail),
+		WithMapExecutor(pool))
+
+	var taskErr *TaskError
+	if !errors.As(err, &taskErr) || taskErr.Key != "0" {
+		t.Errorf("Expected a *TaskError for key <0>; Got <%v>", err)
+	}
+	if errors.Is(err, context.Canceled) {
+		t.Errorf("Expected failing fast not to be reported as a cancellation; Got <%v>", err)
+	}
+	if len(result) != 0 {
+		t.Errorf("Expected no results; Got <%v>", result)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if mapped >= 99 {
+		t.Errorf("Expected the job to stop early; Got <%d> mapper calls", mapped)
+	}
+}
+
+func TestPanicPolicySkip(t *testing.T) {
+	input := []MRInput{
+		{Key: "line1", Values: []string{"the dog"}},
+		{Key: "line2", Values: []string{"the boom"}},
+	}
+
+	result, warnings := MapReduceWithWarnings(input, panickyWordMap, countReduce, WithPanicPolicy(PanicSkip))
+
+	expected := map[string][]string{"the": {"1"}, "dog": {"1"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "found a boom") {
+		t.Errorf("Expected the panic as a warning; Got <%v>", warnings)
+	}
+}
+
+func TestWithMaxErrors(t *testing.T) {
+	// Each "bad" key has a value SumIntReduce can't parse. In key order, the bad keys are all reduced before the good.
+	inputFor := func(bad int) []MRInput {
+		var input []MRInput
+		for i := 0; i < bad; i++ {
+			input = append(input, MRInput{Key: "bad" + strconv.Itoa(i), Values: []string{"x"}})
+		}
+		for i := 0; i < 50; i++ {
+			input = append(input, MRInput{Key: "good" + strconv.Itoa(i), Values: []string{"1"}})
+		}
+		return input
+	}
+
+	// Just under the threshold, the job runs to completion.
+	result, err := TryMapReduce(inputFor(3), IdentityMap, SumIntReduce, WithMaxErrors(3), WithSequential())
+	if err == nil || strings.Contains(err.Error(), "aborted") {
+		t.Errorf("Expected the reducers' errors alone; Got <%v>", err)
+	}
+	if len(result) != 50 {
+		t.Errorf("Expected <50> good keys; Got <%d>", len(result))
+	}
+
+	// Just over it, the job is aborted with the results so far.
+	result, err = TryMapReduce(inputFor(4), IdentityMap, SumIntReduce, WithMaxErrors(3), WithSequential())
+	if err == nil || !strings.Contains(err.Error(), "aborted after more than 3 errors") {
+		t.Errorf("Expected the job to be aborted; Got <%v>", err)
+	}
+	if errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the abort not to be reported as a cancellation; Got <%v>", err)
+	}
+	if len(result) >= 50 {
+		t.Errorf("Expected the good keys to be cut short; Got <%d>", len(result))
+	}
+}
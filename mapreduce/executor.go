@@ -0,0 +1,99 @@
+package mapreduce
+
+import "sync"
+
+// Executor runs the map and reduce tasks of a job. By default every task gets a goroutine of its own; an Executor
+// can instead e.g. bound how many run at once. Execute may block, but it must not wait for the task to complete, as
+// tasks can't complete until the collector runs, and the collector may be waiting on tasks still to be submitted.
+type Executor interface {
+	Execute(task func())
+}
+
+// goExecutor is the default Executor, which runs each task on a new goroutine.
+type goExecutor struct{}
+
+func (goExecutor) Execute(task func()) {
+	go task()
+}
+
+// sequentialExecutor is the Executor of WithSequential, which runs each task to completion before Execute returns.
+// Unlike an Executor of the caller's, it may wait for the task, as jobs submit their tasks from a goroutine other
+// than the collector's and a job's tasks never wait on others of its tasks still to be submitted.
+type sequentialExecutor struct{}
+
+func (sequentialExecutor) Execute(task func()) {
+	task()
+}
+
+// Pool is an Executor that runs tasks on a fixed number of goroutines. Execute blocks until one of them is free.
+// Map tasks that are I/O bound, for example, might get a large Pool and CPU bound reduce tasks a small one.
+type Pool struct {
+	tasks chan func()
+	wg    sync.WaitGroup
+	size  int
+}
+
+// NewPool starts a Pool of size goroutines. They run until the Pool is closed. A size below 1 is taken to be 1, as
+// a Pool without goroutines would never run anything.
+func NewPool(size int) *Pool {
+	if size < 1 {
+		size = 1
+	}
+	p := &Pool{tasks: make(chan func()), size: size}
+	p.wg.Add(size)
+	for i := 0; i < size; i++ {
+		go func() {
+			defer p.wg.Done()
+			for task := range p.tasks {
+				task()
+			}
+		}()
+	}
+	return p
+}
+
+// Execute runs task on the first of the Pool's goroutines to become free.
+func (p *Pool) Execute(task func()) {
+	p.tasks <- task
+}
+
+// Close stops the Pool's goroutines once they've finished their current tasks, and waits for them to exit. The Pool
+// must not be used afterwards. Jobs stop submitting tasks before they return, even when cancelled, so a Pool can be
+// closed as soon as the jobs using it have returned.
+func (p *Pool) Close() {
+	close(p.tasks)
+	p.wg.Wait()
+}
+
+// globalPool is the Pool set by SetGlobalPool, if any.
+var globalPool struct {
+	sync.Mutex
+	pool *Pool
+}
+
+// SetGlobalPool makes every job started from now on run its map and reduce tasks on one shared Pool of size
+// goroutines, unless it is given its own executor, so that many concurrent jobs together run no more than size tasks
+// at once. A size of zero or less goes back to a goroutine per task. It closes any previous global Pool, so it must
+// not be called while jobs using that Pool are running. Each task holds one of the Pool's goroutines until it has
+// finished, so map and reduce functions mustn't themselves wait on jobs that use the global Pool.
+func SetGlobalPool(size int) {
+	globalPool.Lock()
+	defer globalPool.Unlock()
+	if globalPool.pool != nil {
+		globalPool.pool.Close()
+		globalPool.pool = nil
+	}
+	if size > 0 {
+		globalPool.pool = NewPool(size)
+	}
+}
+
+// defaultExecutor returns the global Pool if there is one, and otherwise the goroutine-per-task executor.
+func defaultExecutor() Executor {
+	globalPool.Lock()
+	defer globalPool.Unlock()
+	if globalPool.pool != nil {
+		return globalPool.pool
+	}
+	return goExecutor{}
+}
@@ -0,0 +1,133 @@
+package mapreduce
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingExecutor runs tasks on goroutines and counts them.
+type countingExecutor struct {
+	tasks int32
+}
+
+func (e *countingExecutor) Execute(task func()) {
+	atomic.AddInt32(&e.tasks, 1)
+	go task()
+}
+
+func TestMapReduceExecutors(t *testing.T) {
+	input := []MRInput{
+		{Key: "line1", Values: []string{"the dog"}},
+		{Key: "line2", Values: []string{"the cat"}},
+	}
+	mapExecutor, reduceExecutor := &countingExecutor{}, &countingExecutor{}
+
+	result := MapReduce(input, wordMap, countReduce, WithMapExecutor(mapExecutor), WithReduceExecutor(reduceExecutor))
+
+	expected := map[string][]string{"the": {"2"}, "dog": {"1"}, "cat": {"1"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+	if mapExecutor.tasks != 2 {
+		t.Errorf("Expected the map executor to run 2 tasks; Got <%d>", mapExecutor.tasks)
+	}
+	if reduceExecutor.tasks != 3 {
+		t.Errorf("Expected the reduce executor to run 3 tasks; Got <%d>", reduceExecutor.tasks)
+	}
+}
+
+func TestPoolBoundsConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	running, peak := 0, 0
+	// Tracks how many mappers are running at once.
+	mapFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		mu.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		mu.Unlock()
+
+		wordMap(input, collectChl, doneChl)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+	}
+	mapPool, reducePool := NewPool(3), NewPool(1)
+	defer mapPool.Close()
+	defer reducePool.Close()
+
+	result := MapReduce(numberedInputs(50), mapFunc, countReduce, WithMapExecutor(mapPool),
+		WithReduceExecutor(reducePool))
+
+	if len(result) != 50 {
+		t.Errorf("Expected 50 results; Got <%d>", len(result))
+	}
+	if peak > 3 {
+		t.Errorf("Expected at most 3 concurrent mappers; Got <%d>", peak)
+	}
+}
+
+func TestSetGlobalPool(t *testing.T) {
+	SetGlobalPool(3)
+	defer SetGlobalPool(0)
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+	// Tracks how many tasks, of any job, are running at once.
+	track := func(fn func(MRInput, chan MRInput, chan struct{})) func(MRInput, chan MRInput, chan struct{}) {
+		return func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+			mu.Lock()
+			running++
+			if running > peak {
+				peak = running
+			}
+			mu.Unlock()
+
+			time.Sleep(time.Millisecond)
+			fn(input, collectChl, doneChl)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := MapReduce(numberedInputs(20), track(wordMap), track(countReduce))
+			if len(result) != 20 {
+				t.Errorf("Expected 20 results; Got <%d>", len(result))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak > 3 {
+		t.Errorf("Expected at most 3 concurrent tasks across all jobs; Got <%d>", peak)
+	}
+}
+
+func TestNewPoolSize(t *testing.T) {
+	for _, size := range []int{0, -3} {
+		pool := NewPool(size)
+
+		// A Pool without goroutines would block here for good.
+		result := MapReduce(numberedInputs(5), wordMap, countReduce, WithMapExecutor(pool), WithReduceExecutor(pool))
+
+		if len(result) != 5 {
+			t.Errorf("Expected <5> keys; Got <%v>", result)
+		}
+		if pool.size != 1 {
+			t.Errorf("Expected <1> goroutine; Got <%d>", pool.size)
+		}
+		pool.Close()
+	}
+}
@@ -0,0 +1,41 @@
+package mapreduce
+
+import (
+	"context"
+	"sync"
+)
+
+// MapReduceFind runs mapFunc over input until it emits a record for which match returns true, and returns that
+// record. The job is cancelled as soon as the match is seen, so mappers that haven't started by then never run, which
+// makes the search cheap when matches are common and the mappers are run on a bounded Executor such as a Pool. If
+// several mappers emit matches at once, which of them is returned is arbitrary. ok is false if nothing matched, in
+// which case err reports any mapper that failed, as in TryMapReduce; once a match is found other failures are
+// ignored.
+func MapReduceFind(input []MRInput, mapFunc MapFunc, match func(MRInput) bool, opts ...Option) (
+	found MRInput, ok bool, err error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var once sync.Once
+	findMap := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		forward(mapPhase, mapFunc, input, collectChl, func(kv MRInput) {
+			if kv.ctl == nil && match(kv) {
+				once.Do(func() {
+					found, ok = kv, true
+					cancel()
+				})
+			}
+		})
+		doneChl <- struct{}{}
+	}
+	// Nothing is reduced; the answer, if any, is found during the map phase.
+	discardReduce := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		doneChl <- struct{}{}
+	}
+
+	out := run(ctx, input, []MapFunc{findMap}, discardReduce, newConfig(opts))
+	if ok {
+		return found, true, nil
+	}
+	return MRInput{}, false, out.err
+}
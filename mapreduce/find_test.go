@@ -0,0 +1,47 @@
+package mapreduce
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestMapReduceFind(t *testing.T) {
+	var calls int32
+	mapFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		atomic.AddInt32(&calls, 1)
+		wordMap(input, collectChl, doneChl)
+	}
+	pool := NewPool(1)
+	defer pool.Close()
+
+	found, ok, err := MapReduceFind(numberedInputs(100), mapFunc, func(kv MRInput) bool { return kv.Key == "word000002" },
+		WithMapExecutor(pool))
+
+	if err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	if !ok || found.Key != "word000002" {
+		t.Errorf("Expected <word000002>; Got <%v>, <%t>", found, ok)
+	}
+	// The pool runs one mapper at a time, so only those queued up before the match was seen run.
+	if n := atomic.LoadInt32(&calls); n >= 100 {
+		t.Errorf("Expected the search to stop early; Got <%d> mapper calls", n)
+	}
+}
+
+func TestMapReduceFindNoMatch(t *testing.T) {
+	found, ok, err := MapReduceFind(numberedInputs(10), wordMap, func(kv MRInput) bool { return kv.Key == "missing" })
+
+	if ok || err != nil {
+		t.Errorf("Expected no match and no error; Got <%v>, <%t>, <%v>", found, ok, err)
+	}
+
+	_, _, err = MapReduceFind(numberedInputs(10), panickyWordMap, func(MRInput) bool { return false })
+	if err != nil {
+		t.Errorf("Unexpected error <%v>", err)
+	}
+	boom := []MRInput{{Key: "line1", Values: []string{"boom"}}}
+	if _, _, err = MapReduceFind(boom, panickyWordMap, func(MRInput) bool { return false }); err == nil {
+		t.Errorf("Expected the failed mapper to be reported")
+	}
+}
@@ -0,0 +1,85 @@
+package mapreduce
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+// FuzzMapReduce runs jobs on inputs, and with mappers, decoded from random data, and checks their results against a
+// straightforward single-threaded implementation of the same job: under WithSequential the results must be equal,
+// values in order and all, and otherwise each key must still have the same values, in any order.
+func FuzzMapReduce(f *testing.F) {
+	f.Add([]byte("the dog\x00the cat\x01sat"), uint8(1), uint8(0))
+	f.Add([]byte("aa\x01ab\x01\x01b\x00\x00ba"), uint8(6), uint8(3))
+	f.Add([]byte{}, uint8(0), uint8(2))
+
+	f.Fuzz(func(t *testing.T, data []byte, behavior uint8, batch uint8) {
+		// Inputs are separated by 0 bytes, and their values by 1 bytes.
+		var input []MRInput
+		for i, line := range bytes.Split(data, []byte{0}) {
+			kv := MRInput{Key: strconv.Itoa(i)}
+			for _, value := range bytes.Split(line, []byte{1}) {
+				kv.Values = append(kv.Values, string(value))
+			}
+			input = append(input, kv)
+		}
+		// The mapper emits each value under its first two bytes, 1 to 4 times over, and if behavior is odd, emits its
+		// input's key alone as well.
+		copies := int(behavior/2%4) + 1
+		emit := func(input MRInput, send func(key string, values []string)) {
+			if behavior%2 == 1 {
+				send("key"+input.Key, nil)
+			}
+			for _, value := range input.Values {
+				key := value
+				if len(key) > 2 {
+					key = key[:2]
+				}
+				for c := 0; c < copies; c++ {
+					send(key, []string{value})
+				}
+			}
+		}
+		fuzzMap := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+			emit(input, func(key string, values []string) {
+				collectChl <- MRInput{Key: key, Values: values}
+			})
+			doneChl <- struct{}{}
+		}
+
+		expected := make(map[string][]string)
+		for _, kv := range input {
+			emit(kv, func(key string, values []string) {
+				if _, ok := expected[key]; !ok {
+					expected[key] = []string{}
+				}
+				expected[key] = append(expected[key], values...)
+			})
+		}
+
+		opts := []Option{WithEmitBatch(int(batch % 4))}
+		sequential, err := TryMapReduce(input, fuzzMap, IdentityReduce, append(opts, WithSequential())...)
+		if err != nil {
+			t.Fatalf("Unexpected error <%v>", err)
+		}
+		if !reflect.DeepEqual(expected, sequential) {
+			t.Errorf("Expected <%v>; Got <%v>", expected, sequential)
+		}
+
+		concurrent, err := TryMapReduce(input, fuzzMap, IdentityReduce, opts...)
+		if err != nil {
+			t.Fatalf("Unexpected error <%v>", err)
+		}
+		for _, result := range []map[string][]string{expected, concurrent} {
+			for _, values := range result {
+				sort.Strings(values)
+			}
+		}
+		if !reflect.DeepEqual(expected, concurrent) {
+			t.Errorf("Expected <%v>; Got <%v>", expected, concurrent)
+		}
+	})
+}
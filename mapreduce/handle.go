@@ -0,0 +1,173 @@
+package mapreduce
+
+import (
+	"context"
+	"sync"
+)
+
+// JobHandle is a map-reduce job running in the background, as started by Start.
+type JobHandle struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	live   *liveResult
+	pause  *pauseGate
+	result map[string][]string
+	err    error
+}
+
+// Start starts a map-reduce job like MapReduceContext, but returns a handle to it straight away rather than waiting
+// for it to complete. So that Snapshot can be called while it runs, the job keeps a copy of its reducers' output
+// alongside the result.
+func Start(ctx context.Context, input []MRInput, mapFunc MapFunc, reduceFunc ReduceFunc, opts ...Option) *JobHandle {
+	ctx, cancel := context.WithCancel(ctx)
+	h := &JobHandle{cancel: cancel, done: make(chan struct{}), live: &liveResult{result: make(map[string][]string)},
+		pause: &pauseGate{done: ctx.Done()}}
+	opts = append([]Option{withLiveResult(h.live), withPauseGate(h.pause)}, opts...)
+	go func() {
+		defer close(h.done)
+		defer cancel()
+		h.result, h.err = MapReduceContext(ctx, input, mapFunc, reduceFunc, opts...)
+	}()
+	return h
+}
+
+// Wait waits for the job to complete and returns its result and error, as MapReduceContext would. It may be called
+// any number of times, from any goroutine.
+func (h *JobHandle) Wait() (map[string][]string, error) {
+	<-h.done
+	return h.result, h.err
+}
+
+// Snapshot returns a copy of the output the job's reducers have sent so far, which is empty until the reduce phase
+// starts and only grows from one call to the next. Once the job has completed, it returns a copy of the result
+// instead, which is the reducers' output as modified by options such as WithFinalizer. It may be called from any
+// goroutine, and the copy is the caller's to keep.
+func (h *JobHandle) Snapshot() map[string][]string {
+	select {
+	case <-h.done:
+		return copyResult(h.result)
+	default:
+		return h.live.snapshot()
+	}
+}
+
+// Pause stops the job from starting tasks until Resume is called. Tasks already running carry on and finish
+// normally, as does one that was being handed to the job's executor at the time, but the rest wait, keeping their
+// place in the queue; the job's results are the same as if it had never been paused. A job that is waiting to be
+// resumed can still be cancelled. Time spent paused counts towards WithStallTimeout, so that shouldn't be combined
+// with pausing for long. Pausing a job that is already paused, or has completed, has no effect.
+func (h *JobHandle) Pause() {
+	h.pause.pause()
+}
+
+// Resume lets a paused job start tasks again. Resuming a job that isn't paused has no effect.
+func (h *JobHandle) Resume() {
+	h.pause.resume()
+}
+
+// Cancel cancels the job, which then completes as MapReduceContext does when its context is cancelled. It doesn't
+// wait for that; call Wait to. Cancelling a job that has already completed has no effect.
+func (h *JobHandle) Cancel() {
+	h.cancel()
+}
+
+// liveResult is a copy of a job's reduce output that the collector adds to as it goes and that can be read while it
+// does.
+type liveResult struct {
+	mu     sync.Mutex
+	result map[string][]string
+}
+
+// add appends values to those of key.
+func (l *liveResult) add(key string, values []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.result[key] = append(l.result[key], values...)
+}
+
+// remove removes key and its values.
+func (l *liveResult) remove(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.result, key)
+}
+
+// snapshot returns a copy of the output added so far.
+func (l *liveResult) snapshot() map[string][]string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return copyResult(l.result)
+}
+
+// withLiveResult has the job copy its reduce output to l as it is collected.
+func withLiveResult(l *liveResult) Option {
+	return func(cfg *config) {
+		cfg.live = l
+	}
+}
+
+// pauseGate holds back the tasks of a job while it is paused, until it is resumed or done is closed.
+type pauseGate struct {
+	mu sync.Mutex
+	// resumed is closed when the job is resumed. It is nil while the job isn't paused.
+	resumed chan struct{}
+	done    <-chan struct{}
+}
+
+func (g *pauseGate) pause() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.resumed == nil {
+		g.resumed = make(chan struct{})
+	}
+}
+
+func (g *pauseGate) resume() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.resumed != nil {
+		close(g.resumed)
+		g.resumed = nil
+	}
+}
+
+// wait returns once the job isn't paused.
+func (g *pauseGate) wait() {
+	g.mu.Lock()
+	resumed := g.resumed
+	g.mu.Unlock()
+	if resumed != nil {
+		select {
+		case <-resumed:
+		case <-g.done:
+		}
+	}
+}
+
+// pausableExecutor is an Executor that waits for its job to be resumed before handing a task to the Executor it
+// wraps.
+type pausableExecutor struct {
+	Executor
+	gate *pauseGate
+}
+
+func (e pausableExecutor) Execute(task func()) {
+	e.gate.wait()
+	e.Executor.Execute(task)
+}
+
+// unwrapExecutor returns the Executor that executor hands its tasks to if it is a pausableExecutor, and otherwise
+// executor itself.
+func unwrapExecutor(executor Executor) Executor {
+	if p, ok := executor.(pausableExecutor); ok {
+		return p.Executor
+	}
+	return executor
+}
+
+// withPauseGate has the job's executors hold back its tasks while g is paused.
+func withPauseGate(g *pauseGate) Option {
+	return func(cfg *config) {
+		cfg.pause = g
+	}
+}
@@ -0,0 +1,167 @@
+package mapreduce
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStartWait(t *testing.T) {
+	input := []MRInput{
+		{Key: "line1", Values: []string{"the dog"}},
+		{Key: "line2", Values: []string{"the cat"}},
+	}
+
+	h := Start(context.Background(), input, wordMap, countReduce)
+	result, err := h.Wait()
+
+	if err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	expected := map[string][]string{"the": {"2"}, "dog": {"1"}, "cat": {"1"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+	if again, _ := h.Wait(); !reflect.DeepEqual(result, again) {
+		t.Errorf("Expected Wait to return the same result again; Got <%v>", again)
+	}
+	h.Cancel()
+}
+
+func TestStartCancel(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	blockingMap := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		if input.Key == "0" {
+			close(started)
+		}
+		<-release
+		wordMap(input, collectChl, doneChl)
+	}
+	defer close(release)
+
+	h := Start(context.Background(), numberedInputs(10), blockingMap, countReduce)
+	<-started
+	h.Cancel()
+	result, err := h.Wait()
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected <%v>; Got <%v>", context.Canceled, err)
+	}
+	if len(result) != 0 {
+		t.Errorf("Expected no results; Got <%v>", result)
+	}
+}
+
+func TestJobHandleSnapshot(t *testing.T) {
+	release := make(chan struct{})
+	gatedReduce := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		<-release
+		countReduce(input, collectChl, doneChl)
+	}
+	input := numberedInputs(10)
+
+	h := Start(context.Background(), input, wordMap, gatedReduce)
+
+	// Let the reducers finish one at a time, checking that each snapshot holds everything the one before did.
+	prev := h.Snapshot()
+	if len(prev) != 0 {
+		t.Errorf("Expected an empty snapshot before any reducer finished; Got <%v>", prev)
+	}
+	for i := 1; i <= len(input); i++ {
+		release <- struct{}{}
+		deadline := time.Now().Add(5 * time.Second)
+		snapshot := h.Snapshot()
+		for len(snapshot) < i && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+			snapshot = h.Snapshot()
+		}
+		if len(snapshot) != i {
+			t.Fatalf("Expected <%d> keys; Got <%v>", i, snapshot)
+		}
+		for k, v := range prev {
+			if !reflect.DeepEqual(v, snapshot[k]) {
+				t.Errorf("Expected <%v: %v> to stay in the snapshot; Got <%v>", k, v, snapshot)
+			}
+		}
+		prev = snapshot
+	}
+
+	result, err := h.Wait()
+	if err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	if !reflect.DeepEqual(result, prev) {
+		t.Errorf("Expected <%v>; Got <%v>", result, prev)
+	}
+	if final := h.Snapshot(); !reflect.DeepEqual(result, final) {
+		t.Errorf("Expected <%v>; Got <%v>", result, final)
+	}
+}
+
+func TestJobHandlePauseResume(t *testing.T) {
+	var started int32
+	proceed := make(chan struct{}, 100)
+	gatedMap := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		atomic.AddInt32(&started, 1)
+		<-proceed
+		wordMap(input, collectChl, doneChl)
+	}
+	pool := NewPool(2)
+	defer pool.Close()
+	input := numberedInputs(20)
+
+	h := Start(context.Background(), input, gatedMap, countReduce, WithMapExecutor(pool))
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !cond() && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if !cond() {
+			t.Fatalf("Gave up waiting for %s", what)
+		}
+	}
+	waitFor("the pool to fill", func() bool { return atomic.LoadInt32(&started) == 2 })
+
+	h.Pause()
+	// The running tasks finish, as may the one the pool was about to take next, but no more start.
+	proceed <- struct{}{}
+	proceed <- struct{}{}
+	proceed <- struct{}{}
+	time.Sleep(50 * time.Millisecond)
+	paused := atomic.LoadInt32(&started)
+	if paused > 3 {
+		t.Errorf("Expected no more than <3> tasks to start while paused; Got <%d>", paused)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := atomic.LoadInt32(&started); n != paused {
+		t.Errorf("Expected <%d> tasks; Got <%d>", paused, n)
+	}
+
+	h.Resume()
+	close(proceed)
+	result, err := h.Wait()
+
+	if err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	if expected := MapReduce(input, wordMap, countReduce); !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
+
+func TestJobHandleCancelWhilePaused(t *testing.T) {
+	before := runtime.NumGoroutine()
+	h := Start(context.Background(), numberedInputs(20), wordMap, countReduce)
+	h.Pause()
+	h.Cancel()
+
+	if _, err := h.Wait(); err != nil && !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected <%v>; Got <%v>", context.Canceled, err)
+	}
+	waitForGoroutines(t, before)
+}
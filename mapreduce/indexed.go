@@ -0,0 +1,81 @@
+package mapreduce
+
+import (
+	"math"
+	"sort"
+)
+
+// EmitIndexed sends key with values, to be placed at index among the values of key, so that a key's values come in
+// the same order however the tasks that sent them were scheduled. A mapper might, for example, use its input's
+// position in the job's input, such as the line number in the key of a LineInputs input, as the index of the values
+// it emits, so that each key's values reach the reducer in input order. Values are ordered by index once all of a
+// phase's output has been collected, those with equal indexes in the order they arrived, and values sent without an
+// index after all of those with one. An index only applies within its phase: reducers need to emit with an index
+// too to order the job's result.
+func EmitIndexed(collectChl chan MRInput, key string, index int, values ...string) {
+	collectChl <- MRInput{Key: key, Values: values, index: index, indexed: true}
+}
+
+// collectIndex records the index of each of result's values, which were appended to key's values after the before
+// values already collected for it. Values collected without an index are recorded as coming last.
+func collectIndex(indexes map[string][]int, key string, before int, result MRInput) {
+	idx := indexes[key]
+	for len(idx) < before {
+		idx = append(idx, math.MaxInt)
+	}
+	for range result.Values {
+		if result.indexed {
+			idx = append(idx, result.index)
+		} else {
+			idx = append(idx, math.MaxInt)
+		}
+	}
+	indexes[key] = idx
+}
+
+// orderByIndex puts the values of each key in indexes in index order, along with their weights, Meta and source
+// keys, if the job has any for them.
+func (j *job) orderByIndex(results map[string][]string, indexes map[string][]int) {
+	for key, idx := range indexes {
+		values := results[key]
+		for len(idx) < len(values) {
+			idx = append(idx, math.MaxInt)
+		}
+		byIndex := indexedValues{idx: idx, values: values}
+		if w := j.weights[key]; len(w) == len(values) {
+			byIndex.weights = w
+		}
+		if m := j.meta[key]; len(m) == len(values) {
+			byIndex.meta = m
+		}
+		if s := j.sourceKeys[key]; len(s) == len(values) {
+			byIndex.sourceKeys = s
+		}
+		sort.Stable(byIndex)
+	}
+}
+
+// indexedValues sorts a key's values by their indexes, keeping whatever else the job has for each value in step.
+type indexedValues struct {
+	idx        []int
+	values     []string
+	weights    []float64
+	meta       []map[string]string
+	sourceKeys []string
+}
+
+func (v indexedValues) Len() int           { return len(v.values) }
+func (v indexedValues) Less(a, b int) bool { return v.idx[a] < v.idx[b] }
+func (v indexedValues) Swap(a, b int) {
+	v.idx[a], v.idx[b] = v.idx[b], v.idx[a]
+	v.values[a], v.values[b] = v.values[b], v.values[a]
+	if v.weights != nil {
+		v.weights[a], v.weights[b] = v.weights[b], v.weights[a]
+	}
+	if v.meta != nil {
+		v.meta[a], v.meta[b] = v.meta[b], v.meta[a]
+	}
+	if v.sourceKeys != nil {
+		v.sourceKeys[a], v.sourceKeys[b] = v.sourceKeys[b], v.sourceKeys[a]
+	}
+}
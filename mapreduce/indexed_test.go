@@ -0,0 +1,60 @@
+package mapreduce
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+func TestEmitIndexed(t *testing.T) {
+	const n = 2000
+	input := numberedInputs(n)
+	// Everything goes under one key, indexed by the input's position, with the mappers yielding to mix up the order
+	// their output arrives in.
+	indexedMap := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		i, _ := strconv.Atoi(input.Key)
+		runtime.Gosched()
+		EmitIndexed(collectChl, "all", i, input.Values...)
+		doneChl <- struct{}{}
+	}
+	var expected []string
+	for i := 0; i < n; i++ {
+		expected = append(expected, fmt.Sprintf("word%06d", i))
+	}
+
+	for run := 0; run < 5; run++ {
+		result := MapReduce(input, indexedMap, IdentityReduce, WithEmitBatch(run))
+
+		if !reflect.DeepEqual(expected, result["all"]) {
+			t.Fatalf("Expected the values in input order; Got <%v>", result["all"])
+		}
+	}
+}
+
+func TestEmitIndexedKeepsWeightsInStep(t *testing.T) {
+	mapFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		// Sent in reverse, the unindexed value last of all.
+		collectChl <- MRInput{Key: "k", Values: []string{"c"}, weights: []float64{3}, index: 2, indexed: true}
+		collectChl <- MRInput{Key: "k", Values: []string{"z"}, weights: []float64{9}}
+		collectChl <- MRInput{Key: "k", Values: []string{"b"}, weights: []float64{2}, index: 1, indexed: true}
+		EmitIndexed(collectChl, "k", 0, "a")
+		doneChl <- struct{}{}
+	}
+	var weights []float64
+	reduceFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		weights = input.Weights()
+		IdentityReduce(input, collectChl, doneChl)
+	}
+
+	result := MapReduce([]MRInput{{Key: "line1"}}, mapFunc, reduceFunc)
+
+	expected := map[string][]string{"k": {"a", "b", "c", "z"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+	if expectedWeights := []float64{0, 2, 3, 9}; !reflect.DeepEqual(expectedWeights, weights) {
+		t.Errorf("Expected <%v>; Got <%v>", expectedWeights, weights)
+	}
+}
@@ -0,0 +1,156 @@
+package mapreduce
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// InputOption configures how the file input helpers read their files.
+type InputOption func(*inputConfig)
+
+// inputConfig holds the settings assembled from a set of InputOptions.
+type inputConfig struct {
+	// decompress, if set, wraps every file's reader, whatever the file's name.
+	decompress func(io.Reader) (io.Reader, error)
+}
+
+// WithDecompressor has the file input helpers read every file through decompress, rather than choosing by the file's
+// name. It suits compressed files without a telling extension, or formats other than gzip.
+func WithDecompressor(decompress func(io.Reader) (io.Reader, error)) InputOption {
+	return func(cfg *inputConfig) {
+		cfg.decompress = decompress
+	}
+}
+
+// LineInputs reads fileName and returns an MRInput for each of its lines, with the line as its only value and
+// "fileName:lineNumber" as its key, numbering lines from 1. Files whose names end in ".gz" are transparently
+// decompressed.
+func LineInputs(fileName string, opts ...InputOption) ([]MRInput, error) {
+	r, err := openInput(fileName, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var inputs []MRInput
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		inputs = append(inputs, MRInput{Key: fmt.Sprintf("%s:%d", fileName, n), Values: []string{scanner.Text()}})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("mapreduce: reading %s: %w", fileName, err)
+	}
+	return inputs, nil
+}
+
+// FromRecords returns an MRInput for each of records, in order, with keyFunc's key for it and valueFunc's value for
+// it as its only value. That saves building the inputs of a job over a slice of typed records by hand, and as keyFunc
+// and valueFunc pick the fields, it needs no reflection.
+func FromRecords[T any](records []T, keyFunc func(T) string, valueFunc func(T) string) []MRInput {
+	inputs := make([]MRInput, len(records))
+	for i, record := range records {
+		inputs[i] = MRInput{Key: keyFunc(record), Values: []string{valueFunc(record)}}
+	}
+	return inputs
+}
+
+// DirInput reads the lines of every regular file in dir and its subdirectories, in lexical order of their paths, and
+// sends an MRInput for each on the returned channel as LineInputs would make it, reading as the channel is received
+// from rather than all at once. Files are read one at a time, on a goroutine of DirInput's. Once ctx is cancelled it
+// stops at the next line, opens no more files, and closes the channel, so that a cancelled job doesn't keep reading
+// files for nothing. The caller must receive from the channel until it is closed, and wait then returns nil, the
+// first error reading dir or one of its files, or ctx.Err() if reading was cut short.
+func DirInput(ctx context.Context, dir string, opts ...InputOption) (inputs <-chan MRInput, wait func() error) {
+	out := make(chan MRInput)
+	var err error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer close(out)
+		err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return fmt.Errorf("mapreduce: reading %s: %w", path, err)
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			return sendLines(ctx, path, opts, out)
+		})
+	}()
+
+	return out, func() error {
+		<-done
+		return err
+	}
+}
+
+// sendLines sends the lines of fileName on out as LineInputs returns them, until ctx is cancelled.
+func sendLines(ctx context.Context, fileName string, opts []InputOption, out chan<- MRInput) error {
+	r, err := openInput(fileName, opts)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		select {
+		case out <- MRInput{Key: fmt.Sprintf("%s:%d", fileName, n), Values: []string{scanner.Text()}}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("mapreduce: reading %s: %w", fileName, err)
+	}
+	return nil
+}
+
+// openInput opens fileName for reading, decompressing it as opts and its name call for.
+func openInput(fileName string, opts []InputOption) (io.ReadCloser, error) {
+	cfg := &inputConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	decompress := cfg.decompress
+	if decompress == nil && strings.HasSuffix(fileName, ".gz") {
+		decompress = func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }
+	}
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("mapreduce: opening input: %w", err)
+	}
+	if decompress == nil {
+		return f, nil
+	}
+	r, err := decompress(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("mapreduce: decompressing %s: %w", fileName, err)
+	}
+	return decompressedFile{Reader: r, file: f}, nil
+}
+
+// decompressedFile reads through a decompressor, closing the underlying file when it is closed.
+type decompressedFile struct {
+	io.Reader
+	file *os.File
+}
+
+func (d decompressedFile) Close() error {
+	if c, ok := d.Reader.(io.Closer); ok {
+		c.Close()
+	}
+	return d.file.Close()
+}
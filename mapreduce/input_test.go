@@ -0,0 +1,172 @@
+package mapreduce
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// writeGzip writes content, gzipped, to a new file called name in a temporary directory and returns its path.
+func writeGzip(t *testing.T, name, content string) string {
+	fileName := filepath.Join(t.TempDir(), name)
+	f, err := os.Create(fileName)
+	if err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	defer f.Close()
+	w := gzip.NewWriter(f)
+	if _, err := w.Write([]byte(content)); err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	return fileName
+}
+
+func TestLineInputsGzip(t *testing.T) {
+	fileName := writeGzip(t, "log.txt.gz", "the dog\nthe cat\n")
+
+	inputs, err := LineInputs(fileName)
+	if err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	expected := []MRInput{
+		{Key: fileName + ":1", Values: []string{"the dog"}},
+		{Key: fileName + ":2", Values: []string{"the cat"}},
+	}
+	if !reflect.DeepEqual(expected, inputs) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, inputs)
+	}
+}
+
+func TestLineInputsWithDecompressor(t *testing.T) {
+	// Without the extension the file would be read as is.
+	fileName := writeGzip(t, "log", "the dog\n")
+	gunzip := func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }
+
+	inputs, err := LineInputs(fileName, WithDecompressor(gunzip))
+	if err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	if len(inputs) != 1 || inputs[0].Values[0] != "the dog" {
+		t.Errorf("Expected the decompressed line; Got <%v>", inputs)
+	}
+
+	if _, err := LineInputs(filepath.Join(t.TempDir(), "missing.gz")); err == nil {
+		t.Errorf("Expected an error for a missing file")
+	}
+}
+
+func TestGetWordsGzip(t *testing.T) {
+	fileName := writeGzip(t, "words.gz", "the quick\nbrown fox")
+
+	words, err := GetWords(fileName)
+	if err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	if expected := "the quick brown fox"; strings.Join(words, " ") != expected {
+		t.Errorf("Expected <%v>; Got <%v>", expected, words)
+	}
+}
+
+func TestDirInput(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	for name, content := range map[string]string{"b.txt": "the cat\n", "a.txt": "the dog\nsat\n", "sub/c.txt": "ran\n"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Unexpected error <%v>", err)
+		}
+	}
+
+	ch, wait := DirInput(context.Background(), dir)
+	var inputs []MRInput
+	for input := range ch {
+		inputs = append(inputs, input)
+	}
+
+	if err := wait(); err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	expected := []MRInput{
+		{Key: filepath.Join(dir, "a.txt") + ":1", Values: []string{"the dog"}},
+		{Key: filepath.Join(dir, "a.txt") + ":2", Values: []string{"sat"}},
+		{Key: filepath.Join(dir, "b.txt") + ":1", Values: []string{"the cat"}},
+		{Key: filepath.Join(dir, "sub", "c.txt") + ":1", Values: []string{"ran"}},
+	}
+	if !reflect.DeepEqual(expected, inputs) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, inputs)
+	}
+}
+
+func TestDirInputCancelled(t *testing.T) {
+	dir := t.TempDir()
+	const files = 100
+	for i := 0; i < files; i++ {
+		content := strings.Repeat("the dog\n", 100)
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("%03d.txt", i)), []byte(content), 0o644); err != nil {
+			t.Fatalf("Unexpected error <%v>", err)
+		}
+	}
+	// Counts the files opened, by way of a decompressor that passes them through as they are.
+	var opened int32
+	passThrough := func(r io.Reader) (io.Reader, error) {
+		atomic.AddInt32(&opened, 1)
+		return r, nil
+	}
+	before := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ch, wait := DirInput(ctx, dir, WithDecompressor(passThrough))
+	for i := 0; i < 250; i++ {
+		<-ch
+	}
+	cancel()
+	for range ch {
+	}
+
+	if err := wait(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected <%v>; Got <%v>", context.Canceled, err)
+	}
+	// The first three files were being read when reading was cancelled, and no more than one further file was opened.
+	if n := atomic.LoadInt32(&opened); n > 4 {
+		t.Errorf("Expected no more than <4> files read; Got <%d>", n)
+	}
+	waitForGoroutines(t, before)
+}
+
+func TestFromRecords(t *testing.T) {
+	type order struct {
+		Customer string
+		Item     string
+	}
+	orders := []order{{"ann", "tea"}, {"bob", "jam"}, {"ann", "bread"}}
+
+	inputs := FromRecords(orders, func(o order) string { return o.Customer }, func(o order) string { return o.Item })
+	expectedInputs := []MRInput{
+		{Key: "ann", Values: []string{"tea"}},
+		{Key: "bob", Values: []string{"jam"}},
+		{Key: "ann", Values: []string{"bread"}},
+	}
+	if !reflect.DeepEqual(inputs, expectedInputs) {
+		t.Errorf("Expected <%v>; Got <%v>", expectedInputs, inputs)
+	}
+
+	// Grouped by customer.
+	result := MapReduce(inputs, IdentityMap, IdentityReduce, WithSequential())
+	expected := map[string][]string{"ann": {"tea", "bread"}, "bob": {"jam"}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
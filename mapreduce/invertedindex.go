@@ -8,7 +8,6 @@ import (
 	"bufio"
 	"fmt"
 	"io/ioutil"
-	"os"
 	"path/filepath"
 	"strconv"
 )
@@ -36,7 +35,7 @@ func RemoveDups(input MRInput, resultChl chan MRInput, doneChl chan struct{}) {
 		set[fileName] = struct{}{}
 	}
 
-	var uniqueFiles []string
+	uniqueFiles := make([]string, 0, len(set))
 	for k := range set {
 		uniqueFiles = append(uniqueFiles, k)
 	}
@@ -53,7 +52,7 @@ func GetKVFiles(dirName string) ([]MRInput, error) {
 		return nil, fmt.Errorf("Unexpected error returned: <%v>", err)
 	}
 
-	var kvFiles []MRInput
+	kvFiles := make([]MRInput, 0, len(files))
 	for i, file := range files {
 		kvFiles = append(kvFiles, MRInput{Key: strconv.Itoa(i), Values: []string{file}})
 	}
@@ -69,6 +68,7 @@ func GetFiles(dirName string) (files []string, err error) {
 		return nil, err
 	}
 
+	files = make([]string, 0, len(fileInfos))
 	for _, fileInfo := range fileInfos {
 		file := fileInfo.Name()
 		files = append(files, filepath.Join(dirName, file))
@@ -77,13 +77,14 @@ func GetFiles(dirName string) (files []string, err error) {
 	return files, err
 }
 
-// GetWords returns a set of words in a given file
-func GetWords(fileName string) ([]string, error) {
-	f, err := os.Open(fileName)
+// GetWords returns a set of words in a given file. Files whose names end in ".gz" are transparently decompressed.
+func GetWords(fileName string, opts ...InputOption) ([]string, error) {
+	f, err := openInput(fileName, opts)
 	if err != nil {
 		fmt.Println("Couldn't open file:", fileName, "Error:", err)
 		return nil, fmt.Errorf("Couldn't open file %s. Error %v", fileName, err)
 	}
+	defer f.Close()
 
 	scanner := bufio.NewScanner(f)
 	var words []string
@@ -0,0 +1,173 @@
+package mapreduce
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// ErrNoConvergence is returned by Iterate when maxIterations is reached before the convergence predicate is satisfied.
+var ErrNoConvergence = errors.New("mapreduce: iteration limit reached before convergence")
+
+// ErrDivergence is wrapped by the error Iterate returns when the WithDivergenceLimit safeguard stops it.
+var ErrDivergence = errors.New("mapreduce: iteration is diverging")
+
+// Iterate runs map-reduce repeatedly, feeding the result of each iteration back in as the input of the next. After
+// each iteration converged is called with the previous and current results (for the first iteration "previous" is
+// the original input grouped by key); iteration stops as soon as it returns true. If maxIterations is greater than
+// zero and is reached first, the last result is returned along with ErrNoConvergence. If a map or reduce function
+// panics, iteration stops and the partial result of that iteration is returned with the error, as in TryMapReduce.
+// See WithDivergenceLimit for stopping a computation whose result keeps growing.
+func Iterate(input []MRInput, mapFunc MapFunc, reduceFunc ReduceFunc,
+	converged func(prev, cur map[string][]string) bool, maxIterations int, opts ...Option) (map[string][]string, error) {
+	cfg := newConfig(opts)
+
+	var memo *reduceMemo
+	if cfg.reduceMemo {
+		memo = newReduceMemo()
+		reduceFunc = memo.wrap(reduceFunc)
+	}
+
+	prev := kvSliceToMap(input)
+	// growth counts the consecutive iterations in which the number of keys grew, starting from growthBase keys.
+	growth, growthBase := 0, len(prev)
+	for i := 1; ; i++ {
+		cur, err := TryMapReduce(input, mapFunc, reduceFunc, opts...)
+		if err != nil {
+			return cur, err
+		}
+		if converged(prev, cur) {
+			return cur, nil
+		}
+		if len(cur) > len(prev) {
+			growth++
+		} else {
+			growth, growthBase = 0, len(cur)
+		}
+		if cfg.divergenceLimit > 0 && growth >= cfg.divergenceLimit {
+			return cur, fmt.Errorf("%w: the number of keys grew in each of the last %d iterations, from %d to %d",
+				ErrDivergence, growth, growthBase, len(cur))
+		}
+		if maxIterations > 0 && i >= maxIterations {
+			return cur, ErrNoConvergence
+		}
+		prev = cur
+		input = mapToKVSlice(cur)
+	}
+}
+
+// DiffResults compares two results, such as those of consecutive Iterate iterations, and returns the keys whose
+// values differ, sorted, including keys that only one of them has. Values are compared in order. For the keys that
+// both have a single numeric value, maxDelta is the largest absolute difference between the two; it is 0 if there
+// are none. A convergence predicate might, for example, accept a maxDelta below some tolerance.
+func DiffResults(prev, cur map[string][]string) (changedKeys []string, maxDelta float64) {
+	for key, curValues := range cur {
+		prevValues, ok := prev[key]
+		if !ok {
+			changedKeys = append(changedKeys, key)
+			continue
+		}
+		if !slices.Equal(prevValues, curValues) {
+			changedKeys = append(changedKeys, key)
+		}
+		if len(prevValues) != 1 || len(curValues) != 1 {
+			continue
+		}
+		p, errP := strconv.ParseFloat(prevValues[0], 64)
+		c, errC := strconv.ParseFloat(curValues[0], 64)
+		if errP == nil && errC == nil && math.Abs(c-p) > maxDelta {
+			maxDelta = math.Abs(c - p)
+		}
+	}
+	for key := range prev {
+		if _, ok := cur[key]; !ok {
+			changedKeys = append(changedKeys, key)
+		}
+	}
+	sort.Strings(changedKeys)
+	return changedKeys, maxDelta
+}
+
+// reduceMemo caches, per key, the values a reducer last saw, and their hash, along with everything it emitted for
+// them.
+type reduceMemo struct {
+	sync.Mutex
+	entries map[string]memoEntry
+}
+
+type memoEntry struct {
+	hash uint64
+	// values are the values the reducer saw, in sorted order, to tell them apart from others with the same hash.
+	values []string
+	output []MRInput
+}
+
+func newReduceMemo() *reduceMemo {
+	return &reduceMemo{entries: make(map[string]memoEntry)}
+}
+
+// wrap returns a ReduceFunc that replays the cached output for reduceFunc when a key's values are unchanged, and
+// otherwise runs reduceFunc, recording its output as it is forwarded to collectChl.
+func (m *reduceMemo) wrap(reduceFunc ReduceFunc) ReduceFunc {
+	return func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		values := append([]string(nil), input.Values...)
+		sort.Strings(values)
+		hash := hashValues(values)
+
+		m.Lock()
+		entry, ok := m.entries[input.Key]
+		m.Unlock()
+		if ok && entry.hash == hash && slices.Equal(entry.values, values) {
+			for _, kv := range entry.output {
+				collectChl <- kv
+			}
+			doneChl <- struct{}{}
+			return
+		}
+
+		var output []MRInput
+		failed := false
+		forward(reducePhase, reduceFunc, input, collectChl, func(kv MRInput) {
+			if kv.ctl != nil && kv.ctl.err != nil {
+				failed = true
+			}
+			output = append(output, kv)
+		})
+
+		// Don't cache the output of a reducer that failed; it should get another chance next time.
+		if !failed {
+			m.Lock()
+			m.entries[input.Key] = memoEntry{hash: hash, values: values, output: output}
+			m.Unlock()
+		}
+		doneChl <- struct{}{}
+	}
+}
+
+// hashValues hashes values, which the caller sorts so that the hash doesn't depend on the order in which mappers
+// happened to deliver them.
+func hashValues(values []string) uint64 {
+	h := fnv.New64a()
+	var n [binary.MaxVarintLen64]byte
+	for _, v := range values {
+		// Prefix each value with its length so that ["ab"] and ["a", "b"], or ["a\x00b"], hash differently.
+		h.Write(n[:binary.PutUvarint(n[:], uint64(len(v)))])
+		h.Write([]byte(v))
+	}
+	return h.Sum64()
+}
+
+// kvSliceToMap is the inverse of mapToKVSlice, merging the values of any repeated keys.
+func kvSliceToMap(kvs []MRInput) map[string][]string {
+	kvMap := make(map[string][]string, len(kvs))
+	for _, kv := range kvs {
+		kvMap[kv.Key] = append(kvMap[kv.Key], kv.Values...)
+	}
+	return kvMap
+}
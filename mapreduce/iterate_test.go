@@ -0,0 +1,183 @@
+package mapreduce
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// countingReduce returns a reducer that increments key "b" up to 3, leaves every other key unchanged, and counts
+// how many times it ran for each key.
+func countingReduce(mu *sync.Mutex, calls map[string]int) ReduceFunc {
+	return func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		mu.Lock()
+		calls[input.Key]++
+		mu.Unlock()
+
+		values := input.Values
+		if input.Key == "b" {
+			n, _ := strconv.Atoi(values[0])
+			if n < 3 {
+				n++
+			}
+			values = []string{strconv.Itoa(n)}
+		}
+		collectChl <- MRInput{Key: input.Key, Values: values}
+		doneChl <- struct{}{}
+	}
+}
+
+func unchanged(prev, cur map[string][]string) bool {
+	return reflect.DeepEqual(prev, cur)
+}
+
+func TestIterate(t *testing.T) {
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	input := []MRInput{{Key: "a", Values: []string{"1"}}, {Key: "b", Values: []string{"0"}}}
+
+	result, err := Iterate(input, IdentityMap, countingReduce(&mu, calls), unchanged, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	expected := map[string][]string{"a": {"1"}, "b": {"3"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+	// b goes 0->1->2->3, then one more iteration is needed to observe that nothing changed.
+	if calls["a"] != 4 || calls["b"] != 4 {
+		t.Errorf("Expected 4 reduce calls per key; Got <%v>", calls)
+	}
+}
+
+func TestIterateMaxIterations(t *testing.T) {
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	input := []MRInput{{Key: "b", Values: []string{"0"}}}
+
+	result, err := Iterate(input, IdentityMap, countingReduce(&mu, calls), unchanged, 2)
+	if err != ErrNoConvergence {
+		t.Errorf("Expected <%v>; Got <%v>", ErrNoConvergence, err)
+	}
+	if result["b"][0] != "2" {
+		t.Errorf("Expected the result of the last iteration; Got <%v>", result)
+	}
+}
+
+func TestIterateReduceMemo(t *testing.T) {
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	input := []MRInput{{Key: "a", Values: []string{"1"}}, {Key: "b", Values: []string{"0"}}}
+
+	result, err := Iterate(input, IdentityMap, countingReduce(&mu, calls), unchanged, 0, WithReduceMemo())
+	if err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	expected := map[string][]string{"a": {"1"}, "b": {"3"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+	// a never changes, so only the first iteration runs its reducer. b's input is different in every iteration.
+	if calls["a"] != 1 {
+		t.Errorf("Expected unchanged key to be reduced once; Got <%d>", calls["a"])
+	}
+	if calls["b"] != 4 {
+		t.Errorf("Expected changing key to be reduced every iteration; Got <%d>", calls["b"])
+	}
+}
+
+func TestReduceMemoDistinctValues(t *testing.T) {
+	memo := newReduceMemo()
+	lenReduce := memo.wrap(func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		collectChl <- MRInput{Key: input.Key, Values: []string{strconv.Itoa(len(input.Values))}}
+		doneChl <- struct{}{}
+	})
+	reduce := func(values ...string) []string {
+		collectChl, doneChl := make(chan MRInput, 1), make(chan struct{}, 1)
+		lenReduce(MRInput{Key: "k", Values: values}, collectChl, doneChl)
+		<-doneChl
+		return (<-collectChl).Values
+	}
+
+	// The one value holds the byte that used to separate values when they were hashed.
+	if got := reduce("a\x00b"); !reflect.DeepEqual([]string{"1"}, got) {
+		t.Errorf("Expected <[1]>; Got <%v>", got)
+	}
+	if got := reduce("a", "b"); !reflect.DeepEqual([]string{"2"}, got) {
+		t.Errorf("Expected <[2]>; Got <%v>", got)
+	}
+
+	// Even values whose hash is the same aren't taken for the ones the output was cached for.
+	memo.entries["k"] = memoEntry{hash: hashValues([]string{"x"}), values: []string{"y"}, output: []MRInput{
+		{Key: "k", Values: []string{"cached"}}}}
+	if got := reduce("x"); !reflect.DeepEqual([]string{"1"}, got) {
+		t.Errorf("Expected <[1]>; Got <%v>", got)
+	}
+}
+
+func TestIterateDivergenceLimit(t *testing.T) {
+	// Every key spawns a new one, so the result grows by a key per iteration and never converges.
+	divergingReduce := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		collectChl <- input
+		collectChl <- MRInput{Key: input.Key + "+", Values: input.Values}
+		doneChl <- struct{}{}
+	}
+	input := []MRInput{{Key: "a", Values: []string{"1"}}}
+
+	result, err := Iterate(input, IdentityMap, divergingReduce, unchanged, 0, WithDivergenceLimit(5))
+
+	if !errors.Is(err, ErrDivergence) {
+		t.Fatalf("Expected <%v>; Got <%v>", ErrDivergence, err)
+	}
+	if !strings.Contains(err.Error(), "from 1 to 6") {
+		t.Errorf("Expected the error to describe the growth; Got <%v>", err)
+	}
+	if len(result) != 6 {
+		t.Errorf("Expected the result of the fifth iteration; Got <%d> keys", len(result))
+	}
+}
+
+func TestDiffResults(t *testing.T) {
+	prev := map[string][]string{"a": {"1"}, "b": {"2.5"}, "c": {"x"}, "d": {"1", "2"}, "gone": {"7"}, "same": {"3"}}
+	cur := map[string][]string{"a": {"1.25"}, "b": {"0.5"}, "c": {"y"}, "d": {"2", "1"}, "new": {"100"}, "same": {"3"}}
+
+	changed, maxDelta := DiffResults(prev, cur)
+
+	if expected := []string{"a", "b", "c", "d", "gone", "new"}; !reflect.DeepEqual(expected, changed) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, changed)
+	}
+	// b moved by 2; keys only one side has, and non-numeric or multi-valued keys, don't count.
+	if maxDelta != 2 {
+		t.Errorf("Expected <2>; Got <%v>", maxDelta)
+	}
+
+	changed, maxDelta = DiffResults(cur, cur)
+	if len(changed) != 0 || maxDelta != 0 {
+		t.Errorf("Expected no differences; Got <%v>, <%v>", changed, maxDelta)
+	}
+}
+
+func TestDiffResultsConvergence(t *testing.T) {
+	// Halves the value of each key, converging on 0.
+	halveReduce := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		f, _ := strconv.ParseFloat(input.Values[0], 64)
+		EmitFloat(collectChl, input.Key, f/2)
+		doneChl <- struct{}{}
+	}
+	withinTolerance := func(prev, cur map[string][]string) bool {
+		_, maxDelta := DiffResults(prev, cur)
+		return maxDelta < 0.01
+	}
+
+	result, err := Iterate([]MRInput{{Key: "x", Values: []string{"1"}}}, IdentityMap, halveReduce, withinTolerance, 100)
+
+	if err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	if expected := []string{"0.0078125"}; !reflect.DeepEqual(expected, result["x"]) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result["x"])
+	}
+}
@@ -0,0 +1,22 @@
+package mapreduce
+
+import "strings"
+
+// JoinValues flattens a result into a single string per key, its values joined with sep, e.g. for writing the result
+// out as text. A key with no values maps to the empty string.
+func JoinValues(result map[string][]string, sep string) map[string]string {
+	joined := make(map[string]string, len(result))
+	for key, values := range result {
+		joined[key] = strings.Join(values, sep)
+	}
+	return joined
+}
+
+// JoinReduce returns a reducer that emits each key's values joined with sep as a single value. A key with no values
+// gets the empty string.
+func JoinReduce(sep string) ReduceFunc {
+	return func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		collectChl <- MRInput{Key: input.Key, Values: []string{strings.Join(input.Values, sep)}}
+		doneChl <- struct{}{}
+	}
+}
@@ -0,0 +1,28 @@
+package mapreduce
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJoinValues(t *testing.T) {
+	result := map[string][]string{"a": {"1", "2", "3"}, "b": {"4"}, "c": {}}
+
+	joined := JoinValues(result, ",")
+
+	expected := map[string]string{"a": "1,2,3", "b": "4", "c": ""}
+	if !reflect.DeepEqual(expected, joined) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, joined)
+	}
+}
+
+func TestJoinReduce(t *testing.T) {
+	input := []MRInput{{Key: "a", Values: []string{"x"}}, {Key: "a", Values: []string{"x"}}, {Key: "b"}}
+
+	result := MapReduce(input, IdentityMap, JoinReduce("|"))
+
+	expected := map[string][]string{"a": {"x|x"}, "b": {""}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
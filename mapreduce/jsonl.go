@@ -0,0 +1,152 @@
+package mapreduce
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// LineError reports an input line that couldn't be read or decoded.
+type LineError struct {
+	// Line is the number of the line, counting from 1.
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("mapreduce: line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// decodedLine is the outcome of decoding one line, delivered on a channel of its own.
+type decodedLine struct {
+	input MRInput
+	err   error
+}
+
+// DecodeJSONLines reads r as JSON lines, each a JSON encoding of an MRInput such as {"Key": "k", "Values": ["v"]},
+// and sends the inputs on the returned channel in the order of their lines. Blank lines are skipped. Lines are read
+// on one goroutine and unmarshaled on workers more, so that decoding heavy objects uses more than one CPU. The
+// channel is closed after the last input or the first line that fails, and wait then returns nil or a *LineError
+// for that line. The caller must receive from the channel until it is closed.
+func DecodeJSONLines(r io.Reader, workers int) (inputs <-chan MRInput, wait func() error) {
+	return DecodeJSONLinesContext(context.Background(), r, workers)
+}
+
+// DecodeJSONLinesContext is like DecodeJSONLines, but stops decoding once ctx is cancelled, closing the channel after
+// the lines already being decoded, whose inputs are discarded. wait then returns ctx.Err(). A read from r that is
+// under way when ctx is cancelled isn't interrupted, so that one still has to return.
+func DecodeJSONLinesContext(ctx context.Context, r io.Reader, workers int) (inputs <-chan MRInput,
+	wait func() error) {
+	if workers < 1 {
+		workers = 1
+	}
+	type line struct {
+		n      int
+		text   []byte
+		result chan decodedLine
+	}
+	lines := make(chan line)
+	// pending holds each line's result channel in line order. Its capacity bounds how far the workers can get ahead
+	// of the consumer.
+	pending := make(chan chan decodedLine, 2*workers)
+	// stop is closed once a line has failed, so that the rest of r isn't read for nothing.
+	stop := make(chan struct{})
+
+	go func() {
+		defer close(lines)
+		defer close(pending)
+		br := bufio.NewReader(r)
+		for n := 1; ; n++ {
+			text, err := br.ReadBytes('\n')
+			if len(bytes.TrimSpace(text)) > 0 {
+				l := line{n: n, text: text, result: make(chan decodedLine, 1)}
+				select {
+				case pending <- l.result:
+				case <-stop:
+					return
+				}
+				lines <- l
+			}
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				result := make(chan decodedLine, 1)
+				result <- decodedLine{err: &LineError{Line: n, Err: err}}
+				select {
+				case pending <- result:
+				case <-stop:
+				}
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for l := range lines {
+				var d decodedLine
+				if err := json.Unmarshal(l.text, &d.input); err != nil {
+					d.err = &LineError{Line: l.n, Err: err}
+				}
+				l.result <- d
+			}
+		}()
+	}
+
+	out := make(chan MRInput)
+	var err error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer close(out)
+		for result := range pending {
+			d := <-result
+			if err != nil {
+				// Keep consuming so that the reader and workers can finish.
+				continue
+			}
+			if d.err != nil {
+				err = d.err
+				close(stop)
+				continue
+			}
+			select {
+			case out <- d.input:
+			case <-ctx.Done():
+				err = ctx.Err()
+				close(stop)
+			}
+		}
+		wg.Wait()
+	}()
+
+	return out, func() error {
+		<-done
+		return err
+	}
+}
+
+// JSONLinesInputs decodes all of r with DecodeJSONLines and returns the inputs, or the first error.
+func JSONLinesInputs(r io.Reader, workers int) ([]MRInput, error) {
+	ch, wait := DecodeJSONLines(r, workers)
+	var inputs []MRInput
+	for input := range ch {
+		inputs = append(inputs, input)
+	}
+	if err := wait(); err != nil {
+		return nil, err
+	}
+	return inputs, nil
+}
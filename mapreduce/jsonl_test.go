@@ -0,0 +1,108 @@
+package mapreduce
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestJSONLinesInputs(t *testing.T) {
+	jsonl := `{"Key": "a", "Values": ["1", "2"]}
+
+{"Key": "b", "Values": ["3"]}
+{"Key": "c"}
+`
+	for _, workers := range []int{1, 4} {
+		inputs, err := JSONLinesInputs(strings.NewReader(jsonl), workers)
+
+		if err != nil {
+			t.Fatalf("Unexpected error <%v>", err)
+		}
+		expected := []MRInput{{Key: "a", Values: []string{"1", "2"}}, {Key: "b", Values: []string{"3"}}, {Key: "c"}}
+		if !reflect.DeepEqual(expected, inputs) {
+			t.Errorf("Workers <%d>: Expected <%v>; Got <%v>", workers, expected, inputs)
+		}
+	}
+}
+
+func TestJSONLinesInputsError(t *testing.T) {
+	var b strings.Builder
+	for i := 1; i <= 100; i++ {
+		if i == 42 {
+			b.WriteString("{not json}\n")
+			continue
+		}
+		fmt.Fprintf(&b, "{\"Key\": \"%d\"}\n", i)
+	}
+
+	_, err := JSONLinesInputs(strings.NewReader(b.String()), 8)
+
+	var lineErr *LineError
+	if !errors.As(err, &lineErr) || lineErr.Line != 42 {
+		t.Errorf("Expected an error on line 42; Got <%v>", err)
+	}
+}
+
+// heavyJSONLines returns n lines each encoding an input with many values.
+func heavyJSONLines(n int) []byte {
+	var buf bytes.Buffer
+	values := make([]string, 200)
+	for i := range values {
+		values[i] = fmt.Sprintf("value %d with some padding to make it heavier", i)
+	}
+	enc := json.NewEncoder(&buf)
+	for i := 0; i < n; i++ {
+		enc.Encode(MRInput{Key: fmt.Sprint(i), Values: values})
+	}
+	return buf.Bytes()
+}
+
+func BenchmarkDecodeJSONLines(b *testing.B) {
+	jsonl := heavyJSONLines(2000)
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			b.SetBytes(int64(len(jsonl)))
+			for i := 0; i < b.N; i++ {
+				if _, err := JSONLinesInputs(bytes.NewReader(jsonl), workers); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// endlessLines is a reader of the same JSON line over and over, for ever.
+type endlessLines struct{}
+
+func (endlessLines) Read(p []byte) (int, error) {
+	line := `{"Key": "k", "Values": ["v"]}` + "\n"
+	n := 0
+	for n+len(line) <= len(p) {
+		n += copy(p[n:], line)
+	}
+	return n, nil
+}
+
+func TestDecodeJSONLinesContextCancelled(t *testing.T) {
+	before := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ch, wait := DecodeJSONLinesContext(ctx, endlessLines{}, 4)
+	for i := 0; i < 10; i++ {
+		<-ch
+	}
+	cancel()
+	for range ch {
+	}
+
+	if err := wait(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected <%v>; Got <%v>", context.Canceled, err)
+	}
+	waitForGoroutines(t, before)
+}
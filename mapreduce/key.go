@@ -0,0 +1,49 @@
+package mapreduce
+
+import "strings"
+
+// keySeparator separates the parts of a composite key, and keyEscape escapes occurrences of either within a part.
+const (
+	keySeparator = '|'
+	keyEscape    = '\\'
+)
+
+// CompositeKey encodes parts as a single key, for grouping by several fields. Parts are separated by '|', with any
+// '|' or '\' within a part escaped by a preceding '\', so that SplitKey recovers exactly the original parts whatever
+// they contain. Composite keys of the same parts are equal, so they group together like any other key. CompositeKey
+// of no parts is the empty key, which SplitKey returns as a single empty part.
+func CompositeKey(parts ...string) string {
+	var b strings.Builder
+	for i, part := range parts {
+		if i > 0 {
+			b.WriteByte(keySeparator)
+		}
+		for j := 0; j < len(part); j++ {
+			if part[j] == keySeparator || part[j] == keyEscape {
+				b.WriteByte(keyEscape)
+			}
+			b.WriteByte(part[j])
+		}
+	}
+	return b.String()
+}
+
+// SplitKey returns the parts of a key made by CompositeKey. A key that was never composed is returned as its parts
+// split at each unescaped '|', with escapes removed; a trailing lone '\' is kept as is.
+func SplitKey(key string) []string {
+	var parts []string
+	var part strings.Builder
+	for i := 0; i < len(key); i++ {
+		switch {
+		case key[i] == keyEscape && i+1 < len(key):
+			i++
+			part.WriteByte(key[i])
+		case key[i] == keySeparator:
+			parts = append(parts, part.String())
+			part.Reset()
+		default:
+			part.WriteByte(key[i])
+		}
+	}
+	return append(parts, part.String())
+}
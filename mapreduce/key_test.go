@@ -0,0 +1,48 @@
+package mapreduce
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompositeKeyRoundTrip(t *testing.T) {
+	tests := [][]string{
+		{"us", "2026-10-14"},
+		{"a|b", "c"},
+		{"a", "|b"},
+		{`a\`, "b"},
+		{`\|`, `|\`, ""},
+		{"", ""},
+		{"single"},
+	}
+	for _, parts := range tests {
+		key := CompositeKey(parts...)
+		if got := SplitKey(key); !reflect.DeepEqual(parts, got) {
+			t.Errorf("Expected <%q>; Got <%q> from key <%q>", parts, got, key)
+		}
+	}
+
+	// Parts containing the separator must not collide with keys that have more parts.
+	if CompositeKey("a|b", "c") == CompositeKey("a", "b", "c") {
+		t.Errorf("Expected distinct keys for distinct parts")
+	}
+}
+
+func TestCompositeKeyGrouping(t *testing.T) {
+	mapFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		collectChl <- MRInput{Key: CompositeKey(input.Values[0], input.Values[1]), Values: []string{"1"}}
+		doneChl <- struct{}{}
+	}
+	input := []MRInput{
+		{Key: "1", Values: []string{"us|east", "web"}},
+		{Key: "2", Values: []string{"us", "east|web"}},
+		{Key: "3", Values: []string{"us|east", "web"}},
+	}
+
+	result := MapReduce(input, mapFunc, countReduce)
+
+	expected := map[string][]string{CompositeKey("us|east", "web"): {"2"}, CompositeKey("us", "east|web"): {"1"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
@@ -6,12 +6,100 @@ by the map-reduce client.
 
 package mapreduce
 
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
 // MRInput defines the structure for inputs to the map and reduce functions.
 type MRInput struct {
 	Key    string
 	Values []string
+	// Meta is optional metadata, such as provenance, that applies to all of Values. Map and reduce functions pass it
+	// on by setting it on the records they emit; see ValueMeta and MapReduceWithMeta.
+	Meta map[string]string
+
+	// ctl is set on records that carry control messages (e.g. warnings) to the collector rather than data.
+	ctl *control
+	// batch holds the inputs grouped into a single map task by BatchInputs, or the map output records grouped into
+	// a single send to the collector under WithEmitBatch.
+	batch []MRInput
+	// broadcast is the value built by WithReduceBroadcast, set on every reduce input.
+	broadcast interface{}
+	// weights holds the weight of each of Values, for records sent by EmitWeighted and the reduce inputs they end up
+	// in. It is nil if none of the values has a weight.
+	weights []float64
+	// sourceKeys holds the key each of a reduce input's Values was emitted with, under WithGroupKey.
+	sourceKeys []string
+	// valueMeta holds the Meta of the record each of a reduce input's Values was emitted in. It is nil if none of
+	// them had any.
+	valueMeta []map[string]string
+	// mapFunc, if set, is the map function for this input in place of the job's, for CoReduce.
+	mapFunc MapFunc
+	// index, if indexed is set, is the position that Values take in their key's values, for records sent by
+	// EmitIndexed.
+	index   int
+	indexed bool
+	// clock is the job's source of time, set on every reduce input; see now.
+	clock clock
+}
+
+// Broadcast returns the value built by the WithReduceBroadcast option for a reducer's input, or nil if the job wasn't
+// given that option.
+func (kv MRInput) Broadcast() interface{} {
+	return kv.broadcast
+}
+
+// now returns the current time by the clock of the job that kv is a reduce input of, so that built-in reducers that
+// depend on the time can be tested with a clock of their own.
+func (kv MRInput) now() time.Time {
+	if kv.clock == nil {
+		return time.Now()
+	}
+	return kv.clock.Now()
+}
+
+// Weights returns the weight of each of a reducer input's values, as sent by EmitWeighted, in the same order as
+// Values, with 0 for values sent without one. It returns nil if none of the input's values were sent with a weight.
+func (kv MRInput) Weights() []float64 {
+	return kv.weights
+}
+
+// SourceKeys returns the key that each of a reducer input's values was emitted with, in the same order as Values, if
+// the job groups by a WithGroupKey function. The input's own Key is then the group's key. It returns nil otherwise.
+func (kv MRInput) SourceKeys() []string {
+	return kv.sourceKeys
+}
+
+// control is the payload of a control record sent on a collect channel.
+type control struct {
+	warning string
+	err     error
+	// failedInput is an input skipped under WithSkipFailedInputs.
+	failedInput *MRInput
 }
 
+// Warn reports a non-fatal diagnostic, such as a skipped record or a coerced value, from a map or reduce function.
+// It must be called on the function's collectChl before signaling done. Warnings are gathered separately from the
+// results, are returned by MapReduceWithWarnings, and never cause a job to fail, even under the PanicFail policy.
+func Warn(collectChl chan MRInput, msg string) {
+	collectChl <- MRInput{ctl: &control{warning: msg}}
+}
+
+// MapFunc is the signature of a mapping function. It receives one input, sends its results on collectChl, and
+// signals on doneChl when it has finished.
+type MapFunc func(input MRInput, collectChl chan MRInput, doneChl chan struct{})
+
+// ReduceFunc is the signature of a reduce function. It follows the same contract as MapFunc. By default the result
+// only contains the keys that reducers emit, so a reducer that signals done without emitting anything filters its
+// input key out of the result; see WithEmptyReducePolicy for keeping such keys.
+type ReduceFunc func(input MRInput, collectChl chan MRInput, doneChl chan struct{})
+
 // MapReduce is the entry point to the map-reduce process. It takes an input to the map-reduce process,
 // the mapping function, and the reduce function. "input" contains key/value pairs that represent the
 // input to the map reduce process.
@@ -20,81 +108,975 @@ type MRInput struct {
 // channel parameters. They are also expected to signal when they have completed processing by sending a
 // message on doneChl.
 //
+// opts configure optional behavior of the run; see the With* functions.
+//
 // MapReduce is a simple function that runs in the same goroutine as the caller. The rest of the map-reduce
 // process runs in separate goroutines.
 //
-func MapReduce(input []MRInput, mapFunc func(input MRInput, collectChan chan MRInput, doneChl chan struct{}),
-	reduceFunc func(input MRInput, collectChan chan MRInput, doneChl chan struct{})) (result map[string][]string) {
-	resultChl := make(chan map[string][]string, 1)
+// If a map or reduce function panics, the rest of the job still runs to completion and MapReduce then panics with an
+// error wrapping a *TaskError that identifies the offending input. Use TryMapReduce to have the error returned instead.
+func MapReduce(input []MRInput, mapFunc MapFunc, reduceFunc ReduceFunc, opts ...Option) (result map[string][]string) {
+	out := run(context.TODO(), input, []MapFunc{mapFunc}, reduceFunc, newConfig(opts))
+	out.mustSucceed()
+	return out.result
+}
+
+// TryMapReduce is like MapReduce, but returns an error rather than panicking if any map or reduce function failed.
+// The error wraps a *TaskError for each function that panicked, along with any errors reported by the built-in
+// reducers, and the result holds whatever the rest of the job produced.
+func TryMapReduce(input []MRInput, mapFunc MapFunc, reduceFunc ReduceFunc, opts ...Option) (
+	result map[string][]string, err error) {
+	return MapReduceContext(context.TODO(), input, mapFunc, reduceFunc, opts...)
+}
+
+// MapReduceContext is like TryMapReduce, but stops early if ctx is cancelled, returning the results reduced so far
+// along with an error that wraps ctx.Err(). Cancellation takes effect at every stage: tasks not yet started are
+// skipped, the collector stops adding to the results, and the job returns without waiting for the workers still
+// running. Those are left to finish in the background, with their output discarded so that their sends never block;
+// once they have, none of the job's goroutines remain.
+func MapReduceContext(ctx context.Context, input []MRInput, mapFunc MapFunc, reduceFunc ReduceFunc,
+	opts ...Option) (result map[string][]string, err error) {
+	out := run(ctx, input, []MapFunc{mapFunc}, reduceFunc, newConfig(opts))
+	return out.result, out.err
+}
+
+// MapReduceWithWarnings is like MapReduce, but also returns the warnings reported via Warn by the map and reduce
+// functions, in the order the collector received them.
+func MapReduceWithWarnings(input []MRInput, mapFunc MapFunc, reduceFunc ReduceFunc, opts ...Option) (
+	result map[string][]string, warnings []string) {
+	out := run(context.TODO(), input, []MapFunc{mapFunc}, reduceFunc, newConfig(opts))
+	out.mustSucceed()
+	return out.result, out.warnings
+}
+
+// MapReduceWithStats is like MapReduce, but also returns statistics about the run.
+func MapReduceWithStats(input []MRInput, mapFunc MapFunc, reduceFunc ReduceFunc, opts ...Option) (
+	result map[string][]string, stats Stats) {
+	out := run(context.TODO(), input, []MapFunc{mapFunc}, reduceFunc, newConfig(opts))
+	out.mustSucceed()
+	return out.result, out.stats
+}
+
+// MapReduceMultiMap is like MapReduce, but runs every one of mapFuncs over each input. Their outputs are merged into
+// a single set of intermediate results before reducing, so mappers that should stay apart must emit keys in distinct
+// namespaces.
+func MapReduceMultiMap(input []MRInput, mapFuncs []MapFunc, reduceFunc ReduceFunc, opts ...Option) (
+	result map[string][]string) {
+	out := run(context.TODO(), input, mapFuncs, reduceFunc, newConfig(opts))
+	out.mustSucceed()
+	return out.result
+}
+
+// MapReduceWithIntermediate is like MapReduce, but also returns the results of the map phase, grouped by key as they
+// were fed to the reducers. It is a copy, so changing it doesn't affect the job, but it does double the memory the
+// intermediate results take up.
+func MapReduceWithIntermediate(input []MRInput, mapFunc MapFunc, reduceFunc ReduceFunc, opts ...Option) (
+	intermediate, final map[string][]string) {
+	resultChl := make(chan outcome, 1)
+	go master(context.TODO(), resultChl, &job{cfg: newConfig(opts), keepIntermediate: true}, []MapFunc{mapFunc},
+		reduceFunc, input)
+	out := <-resultChl
+	out.mustSucceed()
+	return out.intermediate, out.result
+}
+
+// MapReduceInto is like MapReduce, but adds input to the result of an earlier run, existing, rather than starting
+// from nothing. Each of existing's keys and values is treated as though a mapper had emitted it, so the reducers see
+// the existing values of a key followed by, or interleaved with, its values from the new input. The reducer must
+// therefore accept its own output as input, as summing does, for which two such runs give the same result as a
+// single run over both inputs; a reducer that counts its input values, say, doesn't. existing isn't modified.
+func MapReduceInto(existing map[string][]string, input []MRInput, mapFunc MapFunc, reduceFunc ReduceFunc,
+	opts ...Option) (result map[string][]string) {
+	resultChl := make(chan outcome, 1)
+	go master(context.TODO(), resultChl, &job{cfg: newConfig(opts), seed: existing}, []MapFunc{mapFunc}, reduceFunc,
+		input)
+	out := <-resultChl
+	out.mustSucceed()
+	return out.result
+}
+
+// MapReduceOrdered is like MapReduce, but returns the result as a slice. The slice is sorted by key, unless the
+// WithInsertionOrder option is given.
+func MapReduceOrdered(input []MRInput, mapFunc MapFunc, reduceFunc ReduceFunc, opts ...Option) []MRInput {
+	out := run(context.TODO(), input, []MapFunc{mapFunc}, reduceFunc, newConfig(opts))
+	out.mustSucceed()
+	kvs := mapToKVSlice(out.result)
+	if out.order != nil {
+		out.order.sort(kvs)
+	} else {
+		sort.Slice(kvs, func(a, b int) bool { return kvs[a].Key < kvs[b].Key })
+	}
+	return kvs
+}
+
+// run starts master and waits for it to report the outcome of the run.
+func run(ctx context.Context, input []MRInput, mapFuncs []MapFunc, reduceFunc ReduceFunc, cfg *config) outcome {
+	resultChl := make(chan outcome, 1)
 
 	// Kick off map/reduce process
-	go master(resultChl, mapFunc, reduceFunc, input)
+	go master(ctx, resultChl, &job{cfg: cfg}, mapFuncs, reduceFunc, input)
 
 	// Wait for result
-	result = <-resultChl
-	return result
+	return <-resultChl
+}
+
+// outcome is what master reports back over its resultChl once a run has completed.
+type outcome struct {
+	result   map[string][]string
+	warnings []string
+	stats    Stats
+	err      error
+	// order is the key order recorded under WithInsertionOrder.
+	order *keyOrder
+	// intermediate is a copy of the map phase's results, if the job kept one.
+	intermediate map[string][]string
+	// meta is the Meta of the result's values, by key, if any were emitted with Meta.
+	meta map[string][]map[string]string
+}
+
+// mustSucceed panics with the outcome's error, if it has one.
+func (out outcome) mustSucceed() {
+	if out.err != nil {
+		panic(out.err)
+	}
+}
+
+// job holds the state that master accumulates over the course of a single run.
+type job struct {
+	cfg *config
+	// stream, if set, receives the reduce output in place of the result map.
+	stream chan MRInput
+	// order, if set, records where each key first appears in the map output.
+	order *keyOrder
+	// keepIntermediate makes master keep a copy of the map phase's results in intermediate.
+	keepIntermediate bool
+	intermediate     map[string][]string
+	// phase is the phase whose output is being collected.
+	phase string
+	// seed, if set, holds intermediate results to start the map phase with, for MapReduceInto.
+	seed map[string][]string
+	// weights holds the weights of the values collected so far, by key, if any were sent with weights.
+	weights map[string][]float64
+	// sourceKeys holds the keys that the values collected so far were emitted with, by key, under WithGroupKey.
+	sourceKeys map[string][]string
+	// meta holds the Meta of the values collected so far, by key, if any were sent with Meta.
+	meta map[string][]map[string]string
+	// failFast, if set, cancels the job on behalf of the PanicFail policy, WithStallTimeout or WithMaxErrors.
+	failFast context.CancelFunc
+	// errorsExceeded records that the job has been aborted under WithMaxErrors.
+	errorsExceeded bool
+	// recordEnc writes the recording of WithRecorder, until recordFailed.
+	recordEnc    *json.Encoder
+	recordFailed bool
+	// spill is the DiskShuffler that the map output has been spilled to under WithMemoryPressureSpill, and
+	// spillSamples the number of records since it was last checked whether to spill.
+	spill        *DiskShuffler
+	spillSamples int
+	// emitBlocks counts the map output sends that had to wait for the collector, for Stats.EmitBlocks.
+	emitBlocks int64
+	warnings   []string
+	errs       []error
+	stats      Stats
 }
 
 // master implements the high level map-reduce algorithm. This mainly consists of (1) starting a goroutine for each
-// of the entries in the inputs parameter to do the mapping; (2) Collecting the results of the mapping process from
-// each of the mapper goroutines; (3) starting a goroutine for each of the entries in the mapping results to perform
-// the reduce operation; (4) collecting the final results and sending them over the resultChl.
-func master(resultChl chan map[string][]string, mapFunc func(input MRInput, collectChl chan MRInput, doneChl chan struct{}),
-	reduceFunc func(input MRInput, collectChl chan MRInput, doneChl chan struct{}), inputs []MRInput) {
+// of the entries in the inputs parameter and each of the mapFuncs to do the mapping; (2) Collecting the results of
+// the mapping process from each of the mapper goroutines; (3) starting a goroutine for each of the entries in the
+// mapping results to perform the reduce operation; (4) collecting the final results, or streaming them if the job
+// has a stream, and sending the outcome over the resultChl. If ctx is cancelled during the map phase no reducers are
+// started; if it is cancelled during the reduce phase the results collected so far are returned.
+func master(ctx context.Context, resultChl chan outcome, j *job, mapFuncs []MapFunc, reduceFunc ReduceFunc,
+	inputs []MRInput) {
+	cfg := j.cfg
+	ctx, endJobSpan := j.startSpan(ctx, "mapreduce.job", "")
+	// The job's outcome reports whether the caller cancelled it, not whether it was cancelled to fail fast.
+	callerCtx := ctx
+	if cfg.panicPolicy == PanicFail || cfg.stallTimeout > 0 || cfg.maxErrors >= 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		j.failFast = cancel
+	}
+	defer func() {
+		if j.spill != nil {
+			j.spill.Close()
+		}
+	}()
+	// finishSink calls the Sink's Finish, once, whichever way the job ends. It has to be called before the outcome is
+	// sent, so that a caller who has the outcome can rely on the Sink being finished.
+	sinkFinished := false
+	finishSink := func() {
+		if cfg.sink != nil && !sinkFinished {
+			sinkFinished = true
+			cfg.sink.Finish()
+		}
+	}
+	if cfg.insertionOrder {
+		j.order = newKeyOrder()
+	}
+
+	if cfg.inputFilter != nil {
+		inputs = filterInputs(inputs, cfg.inputFilter)
+	}
+	if cfg.sample != nil {
+		inputs = filterInputs(inputs, cfg.sample.keep)
+	}
+
+	if cfg.dryRun {
+		j.stats.MapTasks = len(mapFuncs)
+		j.dryRun(ctx, mapFuncs, inputs)
+		finishSink()
+		endJobSpan()
+		resultChl <- j.outcome(callerCtx, make(map[string][]string))
+		return
+	}
 
 	// Used to collect the results from the mapping and reduce operations.
-	collectChl := make(chan MRInput)
-	// Used by workers to signal when they've completed
-	doneChl := make(chan struct{})
+	collectChl := make(chan MRInput, cfg.collectBuffer)
+	j.stats.CollectBuffer = cfg.collectBuffer
+	// Used by mappers to signal when they've completed. It's buffered so that a finished worker never waits on the
+	// collector to hear about it.
+	numResults := len(inputs) * len(mapFuncs)
+	doneChl := make(chan struct{}, numResults)
 
-	// Spawn a mapper goroutine for each input, with a mapping function and a
-	// channel to collect the intermediate results.
-	for _, input := range inputs {
-		go mapFunc(input, collectChl, doneChl)
+	// Spawn a mapper for each input and mapping function, with a channel to collect
+	// the intermediate results. Tasks are submitted from their own goroutine so that
+	// an executor that blocks while it is busy can't keep the collector from running.
+	// Once the job is cancelled, tasks that haven't started are skipped, but still signal done so that drain can
+	// account for them. Master waits for submission to finish before returning, so that once a job has returned it
+	// no longer uses its executors.
+	submitted := make(chan struct{})
+	j.phase = mapPhase
+	go func() {
+		defer close(submitted)
+		for i, input := range inputs {
+			for m, mapFunc := range mapFuncs {
+				pos, input, mapFunc := emitPosition{input: i, mapper: m}, input, mapFunc
+				if ctx.Err() != nil {
+					doneChl <- struct{}{}
+					continue
+				}
+				cfg.mapExecutor.Execute(func() {
+					if ctx.Err() != nil {
+						doneChl <- struct{}{}
+						return
+					}
+					j.labelTask(ctx, mapPhase, func() {
+						j.traceTask(ctx, "mapreduce.map", input.Key, doneChl, func(doneChl chan struct{}) {
+							j.timeTask(mapPhase, input.Key, collectChl, doneChl, func(collectChl chan MRInput,
+								doneChl chan struct{}) {
+								j.mapTask(ctx, pos, mapFunc, input, collectChl, doneChl)
+							})
+						})
+					})
+				})
+			}
+		}
+	}()
+
+	j.stats.MapTasks = numResults
+	intermediateResultMap := j.collectResults(ctx, collectChl, numResults, doneChl)
+	j.stats.EmitBlocks = int(atomic.LoadInt64(&j.emitBlocks))
+	// Every task may have signaled done before the submitting goroutine has returned, and submitted is reused below.
+	<-submitted
+	if ctx.Err() != nil {
+		if j.stream != nil {
+			close(j.stream)
+		}
+		finishSink()
+		endJobSpan()
+		resultChl <- j.outcome(callerCtx, make(map[string][]string))
+		return
 	}
 
-	numResults := len(inputs)
-	intermediateResultMap := collectResults(collectChl, numResults, doneChl)
+	if j.keepIntermediate {
+		j.intermediate = copyResult(intermediateResultMap)
+	}
+	if cfg.barrier != nil {
+		cfg.barrier(intermediateResultMap)
+	}
+	j.phase = reducePhase
+	// Weights only make sense for the reducers' input; any that reducers emit are discarded.
+	weights, sourceKeys, meta := j.weights, j.sourceKeys, j.meta
+	j.weights, j.sourceKeys, j.meta = nil, nil, nil
 
 	// Spawn a reduce goroutine for each mapping result, with a reduce function and a
 	// channel to collect the results. First though, convert the intermediate results into
-	// a slice of MRInputs suitable for input for the reduce function.
-	intermediateResults := mapToKVSlice(intermediateResultMap)
-	for _, intermediateResult := range intermediateResults {
-		go reduceFunc(intermediateResult, collectChl, doneChl)
+	// a slice of MRInputs suitable for input for the reduce function, unless the reducers
+	// are to be fed straight from the intermediate map.
+	numResults = len(intermediateResultMap)
+	doneChl = make(chan struct{}, numResults)
+	if cfg.reducerRouter != nil {
+		reduceFunc = routeReduce(cfg.reducerRouter, reduceFunc)
+	}
+	if cfg.reduceTree != nil {
+		reduceFunc = treeReduce(cfg.reduceTree, cfg.combineIdentity, reduceFunc)
+	}
+	if cfg.detectKeyCollision {
+		reduceFunc = j.detectKeyCollisions(reduceFunc)
+	}
+	var broadcast interface{}
+	if cfg.broadcast != nil {
+		broadcast = cfg.broadcast(intermediateResultMap)
+	}
+	reduce := func(input MRInput, doneChl chan struct{}) {
+		input.broadcast = broadcast
+		input.weights = weights[input.Key]
+		input.valueMeta = meta[input.Key]
+		input.sourceKeys = sourceKeys[input.Key]
+		if ctx.Err() != nil {
+			doneChl <- struct{}{}
+			return
+		}
+		cfg.reduceExecutor.Execute(func() {
+			if ctx.Err() != nil {
+				doneChl <- struct{}{}
+				return
+			}
+			j.wrappedReduceTask(ctx, reduceFunc, input, collectChl, doneChl)
+		})
+	}
+	submitted = make(chan struct{})
+	// The number of groups reduced under WithShuffler, set once submitted is closed.
+	shuffledGroups := 0
+	shuffler := j.shuffler()
+	if shuffler != nil {
+		// The groups are reduced as they come out of the Shuffler, under a single task as far as the collector is
+		// concerned.
+		numResults = 1
+		doneChl = make(chan struct{}, numResults)
+		go func(doneChl chan struct{}) {
+			defer close(submitted)
+			shuffledGroups = j.reduceShuffled(ctx, reduce, collectChl, doneChl)
+		}(doneChl)
+	} else if cfg.balancedReduce && !cfg.sequential {
+		go func(doneChl chan struct{}) {
+			defer close(submitted)
+			j.reduceBalanced(intermediateResultMap, reduce, doneChl)
+		}(doneChl)
+	} else if cfg.lazyReduceInput && !cfg.sequential {
+		go func(doneChl chan struct{}) {
+			defer close(submitted)
+			for key, values := range intermediateResultMap {
+				reduce(MRInput{Key: key, Values: values}, doneChl)
+			}
+		}(doneChl)
+	} else {
+		intermediateResults := mapToKVSlice(intermediateResultMap)
+		if cfg.sequential {
+			sort.Slice(intermediateResults, func(a, b int) bool {
+				return intermediateResults[a].Key < intermediateResults[b].Key
+			})
+		}
+		go func(doneChl chan struct{}) {
+			defer close(submitted)
+			for _, intermediateResult := range intermediateResults {
+				reduce(intermediateResult, doneChl)
+			}
+		}(doneChl)
+	}
+
+	j.stats.ReduceTasks = numResults
+	var finalResults map[string][]string
+	if j.stream != nil {
+		j.streamResults(ctx, collectChl, numResults, doneChl)
+		close(j.stream)
+	} else {
+		finalResults = j.collectResults(ctx, collectChl, numResults, doneChl)
+		finishSink()
+		if ctx.Err() == nil {
+			cfg.emptyReduce.fillEmpty(intermediateResultMap, finalResults)
+		}
+		if cfg.regroupKey != nil && ctx.Err() == nil {
+			finalResults = j.regroup(ctx, finalResults, collectChl)
+		}
+		if cfg.valueSet {
+			sortResultValues(finalResults)
+		}
+		if cfg.valueEqual != nil {
+			dedupResult(finalResults, cfg.valueEqual)
+		}
+		if cfg.outputDir != "" && ctx.Err() == nil {
+			j.writePerKeyOutput(finalResults)
+		}
+		if cfg.finalizer != nil && ctx.Err() == nil {
+			finalResults = cfg.finalizer(finalResults)
+		}
+		if cfg.resultValidator != nil && ctx.Err() == nil && len(j.errs) == 0 {
+			if err := cfg.resultValidator(finalResults); err != nil {
+				j.errs = append(j.errs, fmt.Errorf("mapreduce: validating the result: %w", err))
+			}
+		}
+	}
+
+	<-submitted
+	if shuffler != nil {
+		j.stats.ReduceTasks = shuffledGroups
+	}
+	finishSink()
+	endJobSpan()
+	resultChl <- j.outcome(callerCtx, finalResults)
+}
+
+// wrappedReduceTask runs reduceFunc on input as a reduce task of the job, under the profiler labels, span and
+// timeout that the job's options give each of its reduce tasks.
+func (j *job) wrappedReduceTask(ctx context.Context, reduceFunc ReduceFunc, input MRInput, collectChl chan MRInput,
+	doneChl chan struct{}) {
+	input.clock = j.cfg.clock
+	j.labelTask(ctx, reducePhase, func() {
+		j.traceTask(ctx, "mapreduce.reduce", input.Key, doneChl, func(doneChl chan struct{}) {
+			j.timeTask(reducePhase, input.Key, collectChl, doneChl, func(collectChl chan MRInput,
+				doneChl chan struct{}) {
+				j.reduceTask(ctx, reduceFunc, input, collectChl, doneChl)
+			})
+		})
+	})
+}
+
+// outcome assembles the outcome of the job, whose error includes ctx's error if ctx was cancelled.
+func (j *job) outcome(ctx context.Context, result map[string][]string) outcome {
+	errs := j.errs
+	if ctx.Err() != nil {
+		errs = append([]error{ctx.Err()}, errs...)
+	}
+	return outcome{result: result, warnings: j.warnings, stats: j.stats, err: errors.Join(errs...), order: j.order,
+		intermediate: j.intermediate, meta: j.meta}
+}
+
+// runTask runs the map or reduce function fn on input. If fn panics, the panic is reported to the collector as a
+// *TaskError, followed by the done signal that fn never got to send, unless it had sent it already. fn signals done
+// on a channel of its own, from which the first signal is passed on to doneChl, so that the task is only ever counted
+// as done once.
+func runTask(phase string, fn func(MRInput, chan MRInput, chan struct{}), input MRInput, collectChl chan MRInput,
+	doneChl chan struct{}) {
+	taskDoneChl := make(chan struct{}, 1)
+	go func() {
+		<-taskDoneChl
+		doneChl <- struct{}{}
+	}()
+	defer func() {
+		if r := recover(); r != nil {
+			collectChl <- MRInput{ctl: &control{err: &TaskError{Phase: phase, Key: input.Key, Value: r}}}
+			// If fn's signal is still waiting to be passed on, it stands for this one; if it has been passed on, this
+			// one is never taken.
+			select {
+			case taskDoneChl <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	fn(input, collectChl, taskDoneChl)
+}
+
+// mapTask runs mapFunc on input, which is at pos in the job's input. Its output is passed on to collectChl through
+// a private channel, so that sends that have to wait for the collector can be counted, the order in which keys first
+// appear in the map output can be recorded if the job is keeping track of that, and records can be sent in batches
+// under WithEmitBatch.
+func (j *job) mapTask(ctx context.Context, pos emitPosition, mapFunc MapFunc, input MRInput,
+	collectChl chan MRInput, doneChl chan struct{}) {
+	send := func(kv MRInput) {
+		select {
+		case collectChl <- kv:
+		default:
+			atomic.AddInt64(&j.emitBlocks, 1)
+			collectChl <- kv
+		}
+	}
+
+	var pending []MRInput
+	emit := func(kv MRInput) {
+		if j.order != nil && kv.ctl == nil {
+			j.order.observe(j.phaseKey(mapPhase, kv), pos)
+			pos.emit++
+		}
+		if j.cfg.emitBatch <= 1 {
+			send(kv)
+			return
+		}
+		pending = append(pending, kv)
+		if len(pending) == j.cfg.emitBatch {
+			send(MRInput{batch: pending})
+			pending = nil
+		}
+	}
+
+	// coalesced is the record that consecutive emits of its key are combined into under WithEmitCoalesce.
+	var coalesced *MRInput
+	flush := func() {
+		if coalesced != nil {
+			emit(*coalesced)
+			coalesced = nil
+		}
+	}
+	batchMapFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		j.runMapTask(ctx, mapFunc, input, collectChl, doneChl)
+	}
+	intercept(mapPhase, batchMapFunc, input, func(kv MRInput) {
+		combine := j.cfg.emitCoalesce
+		// Records with weights, Meta or an index per value can't be combined without losing them.
+		if combine == nil || kv.ctl != nil || kv.weights != nil || kv.Meta != nil || kv.indexed {
+			flush()
+			emit(kv)
+			return
+		}
+		if coalesced != nil && coalesced.Key == kv.Key {
+			coalesced.Values = coalesceValues(coalesced.Values, kv.Values, combine)
+			return
+		}
+		flush()
+		coalesced = &MRInput{Key: kv.Key, Values: coalesceValues(nil, kv.Values, combine)}
+		if len(coalesced.Values) == 0 && j.cfg.combineIdentity != nil {
+			coalesced.Values = []string{j.cfg.combineIdentity[0]}
+		}
+	})
+	flush()
+	if len(pending) > 0 {
+		send(MRInput{batch: pending})
+	}
+	doneChl <- struct{}{}
+}
+
+// coalesceValues combines values into acc, which holds at most one value, the combination of those before.
+func coalesceValues(acc, values []string, combine func(a, b string) string) []string {
+	for _, value := range values {
+		if len(acc) == 0 {
+			acc = []string{value}
+			continue
+		}
+		acc[0] = combine(acc[0], value)
+	}
+	return acc
+}
+
+// runMapTask runs mapFunc on input, or if input is a batch, on each of the inputs in it in turn, signaling doneChl
+// once for the whole batch. Each input of a batch runs in isolation, with a done channel of its own, so that a
+// panic is reported against that input's key and the rest of the batch still runs, and an input that signals done
+// more than once can't be taken for the next one finishing.
+func (j *job) runMapTask(ctx context.Context, mapFunc MapFunc, input MRInput, collectChl chan MRInput,
+	doneChl chan struct{}) {
+	if input.batch == nil {
+		j.mapInput(ctx, mapFunc, input, collectChl, doneChl)
+		return
+	}
+
+	mapInput := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		j.mapInput(ctx, mapFunc, input, collectChl, doneChl)
+	}
+	for _, batchInput := range input.batch {
+		intercept(mapPhase, mapInput, batchInput, func(kv MRInput) {
+			collectChl <- kv
+		})
+	}
+	doneChl <- struct{}{}
+}
+
+// mapInput runs mapFunc on a single input. Under WithSkipFailedInputs or WithMapRetries, mapFunc's output is held
+// back until it has finished. If it failed, it is run again as many times as WithMapRetries allows, and if it never
+// succeeds, under WithSkipFailedInputs the data and errors of its last attempt are discarded and the input is
+// reported as failed instead.
+func (j *job) mapInput(ctx context.Context, mapFunc MapFunc, input MRInput, collectChl chan MRInput,
+	doneChl chan struct{}) {
+	if input.mapFunc != nil {
+		mapFunc = input.mapFunc
+	}
+	if !j.cfg.skipFailedInputs && j.cfg.mapRetries <= 0 {
+		runTask(mapPhase, mapFunc, input, collectChl, doneChl)
+		return
 	}
 
-	numResults = len(intermediateResults)
-	finalResults := collectResults(collectChl, numResults, doneChl)
+	output, failed := j.attemptMap(ctx, mapFunc, input)
+	if failed && j.cfg.skipFailedInputs {
+		// Warnings are diagnostics about the input, so they're still worth reporting.
+		for _, kv := range output {
+			if kv.ctl != nil && kv.ctl.err == nil {
+				collectChl <- kv
+			}
+		}
+		collectChl <- MRInput{ctl: &control{failedInput: &input}}
+	} else {
+		for _, kv := range output {
+			collectChl <- kv
+		}
+	}
+	doneChl <- struct{}{}
+}
 
-	resultChl <- finalResults
+// forward runs fn on input like runTask, but through a private collect channel, passing each record fn emits to
+// observe before sending it on to collectChl. It returns once fn has signaled done, leaving it to the caller to
+// signal doneChl in its stead.
+func forward(phase string, fn func(MRInput, chan MRInput, chan struct{}), input MRInput, collectChl chan MRInput,
+	observe func(kv MRInput)) {
+	intercept(phase, fn, input, func(kv MRInput) {
+		observe(kv)
+		collectChl <- kv
+	})
+}
+
+// intercept runs fn on input like runTask, but through a private collect channel, passing each record fn emits to
+// handle. It returns once fn has signaled done.
+func intercept(phase string, fn func(MRInput, chan MRInput, chan struct{}), input MRInput, handle func(kv MRInput)) {
+	localCollectChl := make(chan MRInput)
+	localDoneChl := make(chan struct{}, 1)
+	go runTask(phase, fn, input, localCollectChl, localDoneChl)
+
+	for {
+		select {
+		case kv := <-localCollectChl:
+			handle(kv)
+		case <-localDoneChl:
+			drainReady(localCollectChl, handle)
+			return
+		}
+	}
 }
 
-func collectResults(collectChl chan MRInput, numProcs int, doneChl chan struct{}) map[string][]string {
+// collectResults gathers the records sent on collectChl, grouped by key, until numProcs workers have signaled on
+// doneChl or ctx is cancelled, whichever comes first.
+func (j *job) collectResults(ctx context.Context, collectChl chan MRInput, numProcs int,
+	doneChl chan struct{}) map[string][]string {
 	results := make(map[string][]string)
+	// Running estimate of the bytes held in results, for Stats.PeakIntermediateBytes.
+	var size int64
+	// The keys whose values have been cut short under WithMaxValuesPerKey.
+	truncated := make(map[string]bool)
+	if j.phase == mapPhase && j.seed != nil {
+		results = copyResult(j.seed)
+		for key, values := range results {
+			// The seed is collected as though it had been emitted, so it is held to the same limit.
+			if max := j.cfg.maxValuesPerKey; max > 0 && len(values) > max {
+				truncated[key] = true
+				j.warnings = append(j.warnings, fmt.Sprintf("mapreduce: truncated the values of key %q to %d", key, max))
+				values = values[:max]
+				results[key] = values
+			}
+			size += int64(len(key))
+			for _, value := range values {
+				size += int64(len(value))
+			}
+		}
+		if shuffler := j.cfg.shuffler; shuffler != nil {
+			// The reducers are fed from the shuffler, so that's where the seed has to go, in key order as it would
+			// be read back from a result.
+			keys := make([]string, 0, len(results))
+			for key := range results {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				if err := shuffler.Add(MRInput{Key: key, Values: results[key]}); err != nil {
+					j.errs = append(j.errs, err)
+				}
+			}
+			results, size = make(map[string][]string), 0
+		}
+	}
+	// The values collected so far, by key, under WithValueSet.
+	sets := make(map[string]map[string]bool)
+	// The index of each of the values collected so far, by key, for the keys that any were sent by EmitIndexed for.
+	// Once collection is over, those keys' values are put in index order.
+	indexes := make(map[string][]int)
+	// The keys of the reduce output by when they were last touched, under WithKeyTTL.
+	var ttl *keyTTL
+	if j.phase == reducePhase && j.cfg.keyTTL > 0 {
+		ttl = newKeyTTL(j.cfg.keyTTL)
+	}
+	// forget drops what the collector keeps about key besides its values, once it has been evicted under WithKeyTTL.
+	forget := func(key string) {
+		delete(truncated, key)
+		delete(sets, key)
+		delete(indexes, key)
+	}
+	defer func() {
+		if ttl != nil {
+			j.evictStale(results, ttl, forget)
+		}
+		j.orderByIndex(results, indexes)
+	}()
+	var add func(key string, result MRInput)
+	collect := func(result MRInput) {
+		if result.ctl != nil {
+			j.handleControl(result.ctl)
+			return
+		}
+		j.countRecord()
+		key := j.intermediateKey(result)
+		j.record(recordedEvent{Phase: j.phase, Event: "emit", Key: key, Values: result.Values})
+		if j.phase == reducePhase && j.cfg.sink != nil {
+			j.cfg.sink.Collect(MRInput{Key: key, Values: result.Values, Meta: result.Meta})
+			return
+		}
+		if shuffler := j.shuffler(); j.phase == mapPhase && shuffler != nil {
+			if err := shuffler.Add(MRInput{Key: key, Values: result.Values}); err != nil {
+				j.errs = append(j.errs, err)
+			}
+			return
+		}
+		add(key, result)
+		if j.underMemoryPressure() {
+			if err := j.spillResults(results); err != nil {
+				j.errs = append(j.errs, err)
+			}
+			size = 0
+		}
+	}
+	// add adds result's values to those of key, which is result's key as it is grouped.
+	add = func(key string, result MRInput) {
+		if j.phase == reducePhase && j.cfg.valueSet {
+			if sets[key] == nil {
+				sets[key] = make(map[string]bool)
+			}
+			result.Values = addToSet(sets[key], result.Values)
+		}
+		values, ok := results[key]
+		if !ok {
+			// Record the key even if it comes with no values, so that emitting a key alone marks it present.
+			values = make([]string, 0, len(result.Values))
+			size += int64(len(key))
+		}
+		if max := j.cfg.maxValuesPerKey; max > 0 && len(values)+len(result.Values) > max {
+			if !truncated[key] {
+				truncated[key] = true
+				j.warnings = append(j.warnings, fmt.Sprintf("mapreduce: truncated the values of key %q to %d", key, max))
+			}
+			keep := max - len(values)
+			if keep < 0 {
+				keep = 0
+			}
+			result.Values = result.Values[:keep]
+			if len(result.weights) > keep {
+				result.weights = result.weights[:keep]
+			}
+		}
+		for _, value := range result.Values {
+			size += int64(len(value))
+		}
+		if size > j.stats.PeakIntermediateBytes {
+			j.stats.PeakIntermediateBytes = size
+		}
+		values = append(values, result.Values...)
+		results[key] = values
+		if j.phase == reducePhase && j.cfg.live != nil {
+			j.cfg.live.add(key, result.Values)
+		}
+		if result.weights != nil || j.weights[key] != nil {
+			j.collectWeights(key, len(values)-len(result.Values), result)
+		}
+		if result.Meta != nil || result.valueMeta != nil || j.meta[key] != nil {
+			j.collectMeta(key, len(values)-len(result.Values), result)
+		}
+		if result.indexed || indexes[key] != nil {
+			collectIndex(indexes, key, len(values)-len(result.Values), result)
+		}
+		if ttl != nil {
+			ttl.touch(key, j.cfg.clock.Now())
+			size -= j.evictStale(results, ttl, forget)
+		}
+		if j.phase == mapPhase && j.cfg.groupKeyFunc != nil {
+			if j.sourceKeys == nil {
+				j.sourceKeys = make(map[string][]string)
+			}
+			for range result.Values {
+				j.sourceKeys[key] = append(j.sourceKeys[key], result.Key)
+			}
+		}
+	}
+
+	// collectAll collects result, or each of the records in it if it is a batch.
+	collectAll := func(result MRInput) {
+		if result.batch == nil {
+			collect(result)
+			return
+		}
+		for _, batchResult := range result.batch {
+			collect(batchResult)
+		}
+	}
+
+	if j.collectors() > 1 {
+		j.collectSharded(ctx, collectChl, numProcs, doneChl, collectAll, add)
+		return results
+	}
 
 	// Each map/reduce process will send a message on doneChl just prior to exiting. This function reduces
 	// numProcs by 1 when signaled on the doneChl until numProcs is 0. I.e., it runs until all mappers/reducers
 	// have exited.
-	for i := 0; numProcs > 0; i++ {
+	for numProcs > 0 {
+		if j.cfg.collectStep != nil {
+			j.cfg.collectStep()
+		}
+		// Under WithStallTimeout the collector gives up on tasks that go too long without sending anything.
+		var stalled <-chan time.Time
+		if j.cfg.stallTimeout > 0 {
+			stalled = j.cfg.clock.After(j.cfg.stallTimeout)
+		}
 		select {
 		case result := <-collectChl:
-			values := results[result.Key]
-			values = append(values, result.Values...)
-			results[result.Key] = values
+			j.sampleBacklog(collectChl)
+			if ctx.Err() != nil {
+				// Nothing is added to the results once the job is cancelled, even if it was sent beforehand.
+				go drain(collectChl, numProcs, doneChl)
+				return results
+			}
+			collectAll(result)
 		case <-doneChl:
+			j.record(recordedEvent{Phase: j.phase, Event: "done"})
 			numProcs--
-			continue
+		case <-stalled:
+			j.errs = append(j.errs, fmt.Errorf("mapreduce: %s phase stalled: nothing received for %v with %d tasks "+
+				"still running", j.phase, j.cfg.stallTimeout, numProcs))
+			if j.failFast != nil {
+				j.failFast()
+			}
+			go drain(collectChl, numProcs, doneChl)
+			return results
+		case <-ctx.Done():
+			go drain(collectChl, numProcs, doneChl)
+			return results
 		}
 	}
+	// A task sends its output before it signals done, but with a buffered collectChl the collector may hear that it's
+	// done first. Whatever is still buffered once every task is done is then yet to be collected, as is anything a
+	// task sent from another goroutine as it signaled done.
+	drainReady(collectChl, collectAll)
 	return results
 }
 
-//mapToKVSlice transforms a map[string][]string to a slice of MRInputs.
+// drainReady passes what is ready to be received from collectChl to collect until nothing is: the records buffered in
+// it, and those of senders already blocked sending on it. Only records sent after it has returned are missed, which
+// for a task is after it has signaled done, in breach of its contract.
+func drainReady(collectChl chan MRInput, collect func(MRInput)) {
+	for {
+		select {
+		case result := <-collectChl:
+			collect(result)
+		default:
+			return
+		}
+	}
+}
+
+// countRecord counts a record received from a map or reduce function, for Stats.MapRecords and ReduceRecords.
+func (j *job) countRecord() {
+	if j.phase == mapPhase {
+		j.stats.MapRecords++
+	} else {
+		j.stats.ReduceRecords++
+	}
+}
+
+// sampleBacklog records the number of messages waiting in collectChl, for Stats.PeakCollectBacklog.
+func (j *job) sampleBacklog(collectChl chan MRInput) {
+	if n := len(collectChl); n > j.stats.PeakCollectBacklog {
+		j.stats.PeakCollectBacklog = n
+	}
+}
+
+// collectWeights records the weights of result's values, which follow the first before values of key. Values that
+// came without weights get a weight of 0.
+func (j *job) collectWeights(key string, before int, result MRInput) {
+	if j.weights == nil {
+		j.weights = make(map[string][]float64)
+	}
+	w := j.weights[key]
+	for len(w) < before {
+		w = append(w, 0)
+	}
+	for i := range result.Values {
+		if i < len(result.weights) {
+			w = append(w, result.weights[i])
+		} else {
+			w = append(w, 0)
+		}
+	}
+	j.weights[key] = w
+}
+
+// drain discards whatever the numProcs workers that are still running send, so that a cancelled job's workers can
+// run to completion instead of blocking forever on collectChl.
+func drain(collectChl chan MRInput, numProcs int, doneChl chan struct{}) {
+	for numProcs > 0 {
+		select {
+		case <-collectChl:
+		case <-doneChl:
+			numProcs--
+		}
+	}
+}
+
+// handleControl records the warning, error or failed input carried by a control record.
+func (j *job) handleControl(ctl *control) {
+	switch {
+	case ctl.err != nil && isPanic(ctl.err) && j.cfg.panicPolicy == PanicSkip:
+		j.warnings = append(j.warnings, ctl.err.Error())
+	case ctl.err != nil:
+		j.errs = append(j.errs, ctl.err)
+		if j.cfg.panicPolicy == PanicFail && isPanic(ctl.err) {
+			j.failFast()
+		}
+		if max := j.cfg.maxErrors; max >= 0 && len(j.errs) > max && !j.errorsExceeded {
+			j.errorsExceeded = true
+			j.errs = append(j.errs, fmt.Errorf("mapreduce: aborted after more than %d errors", max))
+			j.failFast()
+		}
+	case ctl.failedInput != nil:
+		j.stats.FailedInputs = append(j.stats.FailedInputs, *ctl.failedInput)
+	default:
+		j.warnings = append(j.warnings, ctl.warning)
+	}
+}
+
+// intermediateKey returns the key that kv, collected in the current phase, is grouped under: its WithGroupKey key, if
+// it is map output and the job has a WithGroupKey function, and otherwise its groupKey. Only the collector's
+// goroutines may call it.
+func (j *job) intermediateKey(kv MRInput) string {
+	return j.phaseKey(j.phase, kv)
+}
+
+// phaseKey is intermediateKey for a record emitted in phase. Map tasks, which can outlive the map phase once they've
+// been abandoned, pass their phase in, rather than read j.phase as master moves on to the reduce phase.
+func (j *job) phaseKey(phase string, kv MRInput) string {
+	if phase == mapPhase && j.cfg.groupKeyFunc != nil {
+		return j.cfg.groupKeyFunc(kv)
+	}
+	return j.groupKey(kv.Key)
+}
+
+// groupKey returns the key that a record emitted with key is grouped under.
+func (j *job) groupKey(key string) string {
+	if j.cfg.keyNormalizer != nil {
+		return j.cfg.keyNormalizer(key)
+	}
+	return key
+}
+
+// filterInputs returns the inputs that keep returns true for, looking inside batches at the inputs they hold.
+func filterInputs(inputs []MRInput, keep func(MRInput) bool) []MRInput {
+	kept := make([]MRInput, 0, len(inputs))
+	for _, input := range inputs {
+		if input.batch != nil {
+			input.batch = filterInputs(input.batch, keep)
+			kept = append(kept, input)
+		} else if keep(input) {
+			kept = append(kept, input)
+		}
+	}
+	return kept
+}
+
+// copyResult returns a copy of result that shares no storage with it.
+func copyResult(result map[string][]string) map[string][]string {
+	c := make(map[string][]string, len(result))
+	for key, values := range result {
+		c[key] = append(make([]string, 0, len(values)), values...)
+	}
+	return c
+}
+
+// mapToKVSlice transforms a map[string][]string to a slice of MRInputs.
 func mapToKVSlice(kvMap map[string][]string) []MRInput {
-	var kvs []MRInput
+	kvs := make([]MRInput, 0, len(kvMap))
 	for key, value := range kvMap {
-		kv := MRInput{key, value}
+		kv := MRInput{Key: key, Values: value}
 		kvs = append(kvs, kv)
 	}
 	return kvs
@@ -0,0 +1,665 @@
+package mapreduce
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// wordMap emits each whitespace-separated word in input.Values[0] with a value of "1", warning about (and skipping)
+// any word that is entirely punctuation.
+func wordMap(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+	for _, word := range strings.Fields(input.Values[0]) {
+		if strings.Trim(word, ".,;!?-") == "" {
+			Warn(collectChl, "skipped <"+word+"> in "+input.Key)
+			continue
+		}
+		collectChl <- MRInput{Key: word, Values: []string{"1"}}
+	}
+	doneChl <- struct{}{}
+}
+
+// countReduce emits the number of values for a key as a single value.
+func countReduce(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+	collectChl <- MRInput{Key: input.Key, Values: []string{strconv.Itoa(len(input.Values))}}
+	doneChl <- struct{}{}
+}
+
+func TestMapReduceWithWarnings(t *testing.T) {
+	input := []MRInput{
+		{Key: "line1", Values: []string{"the dog - ran"}},
+		{Key: "line2", Values: []string{"the cat !"}},
+	}
+
+	result, warnings := MapReduceWithWarnings(input, wordMap, countReduce)
+
+	expected := map[string][]string{"the": {"2"}, "dog": {"1"}, "ran": {"1"}, "cat": {"1"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+
+	sort.Strings(warnings)
+	expectedWarnings := []string{"skipped <!> in line2", "skipped <-> in line1"}
+	if !reflect.DeepEqual(expectedWarnings, warnings) {
+		t.Errorf("Expected warnings <%v>; Got <%v>", expectedWarnings, warnings)
+	}
+}
+
+func TestMapReduceIgnoresWarnings(t *testing.T) {
+	input := []MRInput{{Key: "line1", Values: []string{"the dog - ran"}}}
+
+	result := MapReduce(input, wordMap, countReduce)
+
+	expected := map[string][]string{"the": {"1"}, "dog": {"1"}, "ran": {"1"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
+
+// repeatedReduce drops keys seen fewer than twice and otherwise behaves like countReduce.
+func repeatedReduce(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+	if len(input.Values) >= 2 {
+		collectChl <- MRInput{Key: input.Key, Values: []string{strconv.Itoa(len(input.Values))}}
+	}
+	doneChl <- struct{}{}
+}
+
+func TestMapReduceFilteringReduce(t *testing.T) {
+	input := []MRInput{
+		{Key: "line1", Values: []string{"the dog ran"}},
+		{Key: "line2", Values: []string{"the cat ran"}},
+	}
+
+	result := MapReduce(input, wordMap, repeatedReduce)
+
+	expected := map[string][]string{"the": {"2"}, "ran": {"2"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+	if _, ok := result["dog"]; ok {
+		t.Errorf("Expected filtered key <dog> to be absent; Got <%v>", result)
+	}
+}
+
+// benchmarkDoneSignal measures how long it takes the collector to see n workers finish when they signal on a done
+// channel with the given buffer size.
+func benchmarkDoneSignal(b *testing.B, n, buffer int) {
+	for i := 0; i < b.N; i++ {
+		collectChl := make(chan MRInput)
+		doneChl := make(chan struct{}, buffer)
+		for w := 0; w < n; w++ {
+			go func() {
+				doneChl <- struct{}{}
+			}()
+		}
+		j := &job{cfg: newConfig(nil)}
+		j.collectResults(context.TODO(), collectChl, n, doneChl)
+	}
+}
+
+func BenchmarkDoneSignalUnbuffered(b *testing.B) {
+	benchmarkDoneSignal(b, 10000, 0)
+}
+
+func BenchmarkDoneSignalBuffered(b *testing.B) {
+	benchmarkDoneSignal(b, 10000, 10000)
+}
+
+func TestMapReduceEmitsUpToDone(t *testing.T) {
+	// Every mapper emits right up to its done signal, so with a buffered collectChl the collector often hears that the
+	// last of them is done before it has received all of their output.
+	emitMap := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		for i := 0; i < 50; i++ {
+			collectChl <- MRInput{Key: strconv.Itoa(i % 5), Values: []string{input.Key}}
+		}
+		doneChl <- struct{}{}
+	}
+	input := numberedInputs(40)
+	for name, opts := range map[string][]Option{
+		"unbuffered": nil,
+		"buffered":   {WithCollectBuffer(64)},
+		"batched":    {WithCollectBuffer(64), WithEmitBatch(7)},
+		"timed":      {WithCollectBuffer(64), WithTaskTimeout(time.Minute)},
+		"sharded":    {WithCollectBuffer(64), WithCollectorGoroutines(4)},
+	} {
+		for i := 0; i < 20; i++ {
+			result := MapReduce(input, emitMap, countReduce, opts...)
+			for key := 0; key < 5; key++ {
+				if got := result[strconv.Itoa(key)]; !reflect.DeepEqual(got, []string{"400"}) {
+					t.Fatalf("%s: Expected <[400]> values of key <%d>; Got <%v>", name, key, got)
+				}
+			}
+		}
+	}
+}
+
+func TestCollectResultsEmitAsDone(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		// A task's last record is sent from another goroutine, which is blocked sending it by the time the task
+		// signals done, so the collector has both to choose from as it starts.
+		collectChl, doneChl := make(chan MRInput), make(chan struct{}, 1)
+		go func() {
+			collectChl <- MRInput{Key: "last", Values: []string{"1"}}
+		}()
+		time.Sleep(10 * time.Millisecond)
+		doneChl <- struct{}{}
+
+		j := &job{cfg: newConfig(nil), phase: mapPhase}
+		result := j.collectResults(context.TODO(), collectChl, 1, doneChl)
+		if !reflect.DeepEqual(result["last"], []string{"1"}) {
+			t.Fatalf("Expected the record sent as the task signaled done; Got <%v>", result)
+		}
+	}
+}
+
+func TestMapReduceLazyReduceInput(t *testing.T) {
+	input := numberedInputs(500)
+	input = append(input, MRInput{Key: "dups", Values: []string{"word000001 word000002"}})
+
+	eagerResult, eagerStats := MapReduceWithStats(input, wordMap, countReduce)
+	lazyResult, lazyStats := MapReduceWithStats(input, wordMap, countReduce, WithLazyReduceInput())
+
+	if !reflect.DeepEqual(eagerResult, lazyResult) {
+		t.Errorf("Expected <%v>; Got <%v>", eagerResult, lazyResult)
+	}
+	if eagerStats.ReduceTasks != 500 || lazyStats.ReduceTasks != eagerStats.ReduceTasks {
+		t.Errorf("Expected 500 reduce tasks either way; Got <%d> eager and <%d> lazy",
+			eagerStats.ReduceTasks, lazyStats.ReduceTasks)
+	}
+}
+
+func TestMapReduceKeyPresence(t *testing.T) {
+	// Each input marks the words in it as present without giving them a value.
+	presenceMap := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		for i, word := range strings.Fields(input.Values[0]) {
+			if i%2 == 0 {
+				collectChl <- MRInput{Key: word}
+			} else {
+				collectChl <- MRInput{Key: word, Values: []string{}}
+			}
+		}
+		doneChl <- struct{}{}
+	}
+	input := []MRInput{
+		{Key: "line1", Values: []string{"the dog"}},
+		{Key: "line2", Values: []string{"the cat"}},
+	}
+
+	result := MapReduce(input, presenceMap, IdentityReduce)
+
+	expected := map[string][]string{"the": {}, "dog": {}, "cat": {}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+	for _, word := range []string{"the", "dog", "cat"} {
+		if values, ok := result[word]; !ok || values == nil {
+			t.Errorf("Expected <%s> to be present with an empty slice; Got <%#v>, present <%t>", word, values, ok)
+		}
+	}
+	if _, ok := result["bird"]; ok {
+		t.Errorf("Expected <bird> to be absent")
+	}
+}
+
+// scriptedRun runs a job whose mappers each warn once and then signal done, both only when released by the
+// collector's step hook. script lists which mapper to release at each step, so it fixes the order in which the
+// collector sees every message. The warnings are returned in the order they were collected.
+func scriptedRun(script []string) []string {
+	release := map[string]chan struct{}{"a": make(chan struct{}, 2), "b": make(chan struct{}, 2)}
+	scriptedMap := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		<-release[input.Key]
+		Warn(collectChl, input.Key)
+		<-release[input.Key]
+		doneChl <- struct{}{}
+	}
+	next := 0
+	step := func() {
+		if next < len(script) {
+			release[script[next]] <- struct{}{}
+			next++
+		}
+	}
+
+	input := []MRInput{{Key: "a"}, {Key: "b"}}
+	_, warnings := MapReduceWithWarnings(input, scriptedMap, IdentityReduce, withCollectStep(step))
+	return warnings
+}
+
+func TestCollectStepOrdering(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		if warnings := scriptedRun([]string{"b", "a", "a", "b"}); !reflect.DeepEqual([]string{"b", "a"}, warnings) {
+			t.Fatalf("Expected <[b a]>; Got <%v>", warnings)
+		}
+		if warnings := scriptedRun([]string{"a", "a", "b", "b"}); !reflect.DeepEqual([]string{"a", "b"}, warnings) {
+			t.Fatalf("Expected <[a b]>; Got <%v>", warnings)
+		}
+	}
+}
+
+func BenchmarkMapToKVSlice(b *testing.B) {
+	kvMap := make(map[string][]string, 1000000)
+	for i := 0; i < 1000000; i++ {
+		kvMap[strconv.Itoa(i)] = []string{"1"}
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		kvs := mapToKVSlice(kvMap)
+		if len(kvs) != len(kvMap) {
+			b.Fatalf("Expected <%d> entries; Got <%d>", len(kvMap), len(kvs))
+		}
+	}
+}
+
+func TestMapReduceKeyNormalizer(t *testing.T) {
+	input := []MRInput{
+		{Key: "line1", Values: []string{"The dog"}},
+		{Key: "line2", Values: []string{"the DOG"}},
+		{Key: "line3", Values: []string{"THE Cat"}},
+	}
+	normalize := func(key string) string {
+		return strings.ToLower(strings.TrimSpace(key))
+	}
+
+	result := MapReduce(input, wordMap, countReduce, WithKeyNormalizer(normalize))
+
+	expected := map[string][]string{"the": {"3"}, "dog": {"2"}, "cat": {"1"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
+
+func TestMapReduceMultiMap(t *testing.T) {
+	input := []MRInput{
+		{Key: "line1", Values: []string{"the dog"}},
+		{Key: "line2", Values: []string{"a cat"}},
+	}
+	// Counts words under "word:" and line lengths, in characters, under "len:".
+	wordsMap := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		for _, word := range strings.Fields(input.Values[0]) {
+			collectChl <- MRInput{Key: "word:" + word, Values: []string{"1"}}
+		}
+		doneChl <- struct{}{}
+	}
+	lengthMap := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		collectChl <- MRInput{Key: "len:" + strconv.Itoa(len(input.Values[0])), Values: []string{input.Key}}
+		doneChl <- struct{}{}
+	}
+
+	result := MapReduceMultiMap(input, []MapFunc{wordsMap, lengthMap}, countReduce)
+
+	expected := map[string][]string{
+		"word:the": {"1"}, "word:dog": {"1"}, "word:a": {"1"}, "word:cat": {"1"},
+		"len:7": {"1"}, "len:5": {"1"},
+	}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
+
+func TestMapReduceBarrier(t *testing.T) {
+	input := []MRInput{
+		{Key: "line1", Values: []string{"the dog"}},
+		{Key: "line2", Values: []string{"the cat"}},
+	}
+	var mu sync.Mutex
+	reduced := 0
+	reduceFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		mu.Lock()
+		reduced++
+		mu.Unlock()
+		countReduce(input, collectChl, doneChl)
+	}
+	calls := 0
+	var seen map[string][]string
+	barrier := func(intermediate map[string][]string) {
+		calls++
+		mu.Lock()
+		if reduced != 0 {
+			t.Errorf("Expected barrier to run before any reducer; <%d> already ran", reduced)
+		}
+		mu.Unlock()
+		seen = make(map[string][]string)
+		for k, v := range intermediate {
+			seen[k] = append([]string(nil), v...)
+		}
+	}
+
+	MapReduce(input, wordMap, reduceFunc, WithBarrier(barrier))
+
+	if calls != 1 {
+		t.Errorf("Expected barrier to be called once; Got <%d>", calls)
+	}
+	expected := map[string][]string{"the": {"1", "1"}, "dog": {"1"}, "cat": {"1"}}
+	if !reflect.DeepEqual(expected, seen) {
+		t.Errorf("Expected barrier to see <%v>; Got <%v>", expected, seen)
+	}
+}
+
+func TestMapReduceFinalizer(t *testing.T) {
+	input := []MRInput{
+		{Key: "line1", Values: []string{"the dog"}},
+		{Key: "line2", Values: []string{"the cat"}},
+	}
+	calls := 0
+	grandTotal := func(result map[string][]string) map[string][]string {
+		calls++
+		total := 0
+		for _, values := range result {
+			n, _ := strconv.Atoi(values[0])
+			total += n
+		}
+		result["*total*"] = []string{strconv.Itoa(total)}
+		return result
+	}
+
+	result := MapReduce(input, wordMap, countReduce, WithFinalizer(grandTotal))
+
+	expected := map[string][]string{"the": {"2"}, "dog": {"1"}, "cat": {"1"}, "*total*": {"4"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+	if calls != 1 {
+		t.Errorf("Expected finalizer to be called once; Got <%d>", calls)
+	}
+}
+
+func TestMapReduceResultValidator(t *testing.T) {
+	input := []MRInput{
+		{Key: "line1", Values: []string{"the dog"}},
+		{Key: "line2", Values: []string{"the cat"}},
+	}
+	errMultipleValues := errors.New("a key has more than one value")
+	oneValueEach := func(result map[string][]string) error {
+		for key, values := range result {
+			if len(values) != 1 {
+				return fmt.Errorf("%w: %q has %d", errMultipleValues, key, len(values))
+			}
+		}
+		return nil
+	}
+
+	result, err := TryMapReduce(input, wordMap, countReduce, WithResultValidator(oneValueEach))
+	if err != nil {
+		t.Errorf("Unexpected error <%v>", err)
+	}
+
+	// The identity reducer leaves "the" with a value for each time it was seen.
+	result, err = TryMapReduce(input, wordMap, IdentityReduce, WithResultValidator(oneValueEach))
+	if !errors.Is(err, errMultipleValues) || !strings.Contains(err.Error(), `"the" has 2`) {
+		t.Errorf("Expected <%v> for key \"the\"; Got <%v>", errMultipleValues, err)
+	}
+	if len(result) != 3 {
+		t.Errorf("Expected the result along with the error; Got <%v>", result)
+	}
+}
+
+func TestMapReduceBroadcast(t *testing.T) {
+	input := []MRInput{
+		{Key: "line1", Values: []string{"the dog"}},
+		{Key: "line2", Values: []string{"the cat"}},
+	}
+	totalWords := func(intermediate map[string][]string) interface{} {
+		total := 0
+		for _, values := range intermediate {
+			total += len(values)
+		}
+		return total
+	}
+	// Emits the share of all words that each word accounts for, as a percentage.
+	shareReduce := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		total := input.Broadcast().(int)
+		EmitInt(collectChl, input.Key, 100*len(input.Values)/total)
+		doneChl <- struct{}{}
+	}
+
+	result := MapReduce(input, wordMap, shareReduce, WithReduceBroadcast(totalWords))
+
+	expected := map[string][]string{"the": {"50"}, "dog": {"25"}, "cat": {"25"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
+
+func TestMapReduceWithIntermediate(t *testing.T) {
+	input := []MRInput{
+		{Key: "line1", Values: []string{"the dog"}},
+		{Key: "line2", Values: []string{"the cat"}},
+	}
+
+	intermediate, final := MapReduceWithIntermediate(input, wordMap, countReduce)
+
+	expectedIntermediate := map[string][]string{"the": {"1", "1"}, "dog": {"1"}, "cat": {"1"}}
+	if !reflect.DeepEqual(expectedIntermediate, intermediate) {
+		t.Errorf("Expected <%v>; Got <%v>", expectedIntermediate, intermediate)
+	}
+	expectedFinal := map[string][]string{"the": {"2"}, "dog": {"1"}, "cat": {"1"}}
+	if !reflect.DeepEqual(expectedFinal, final) {
+		t.Errorf("Expected <%v>; Got <%v>", expectedFinal, final)
+	}
+}
+
+func TestMapReduceWithIntermediateIsACopy(t *testing.T) {
+	input := []MRInput{{Key: "line1", Values: []string{"the dog the"}}}
+	// The barrier sees the job's own intermediate results, after the copy has been taken.
+	var internal map[string][]string
+	barrier := WithBarrier(func(intermediate map[string][]string) { internal = intermediate })
+
+	intermediate, _ := MapReduceWithIntermediate(input, wordMap, countReduce, barrier)
+	intermediate["the"][0] = "changed"
+	delete(intermediate, "dog")
+
+	expected := map[string][]string{"the": {"1", "1"}, "dog": {"1"}}
+	if !reflect.DeepEqual(expected, internal) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, internal)
+	}
+}
+
+// manyEmitsMap emits the numbers from 0 to 9999 under key "n", and each number under its own key.
+func manyEmitsMap(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+	for i := 0; i < 10000; i++ {
+		collectChl <- MRInput{Key: "n", Values: []string{strconv.Itoa(i)}}
+		collectChl <- MRInput{Key: strconv.Itoa(i), Values: []string{"1"}}
+	}
+	doneChl <- struct{}{}
+}
+
+func TestWithEmitBatch(t *testing.T) {
+	input := []MRInput{{Key: "1"}}
+	expected := MapReduce(input, manyEmitsMap, IdentityReduce)
+
+	for _, n := range []int{2, 64, 30000} {
+		result, warnings := MapReduceWithWarnings(input, manyEmitsMap, IdentityReduce, WithEmitBatch(n))
+
+		// With a single mapper, the values of "n" are in the order they were emitted, batched or not.
+		if !reflect.DeepEqual(expected, result) {
+			t.Errorf("Batch <%d>: Expected the unbatched result", n)
+		}
+		if len(warnings) != 0 {
+			t.Errorf("Batch <%d>: Unexpected warnings <%v>", n, warnings)
+		}
+	}
+
+	// Warnings travel in batches along with the records.
+	line := []MRInput{{Key: "line1", Values: []string{"the dog ! the cat"}}}
+	result, warnings := MapReduceWithWarnings(line, wordMap, countReduce, WithEmitBatch(3))
+	if expected := map[string][]string{"the": {"2"}, "dog": {"1"}, "cat": {"1"}}; !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("Expected 1 warning; Got <%v>", warnings)
+	}
+}
+
+func BenchmarkEmitBatch(b *testing.B) {
+	input := []MRInput{{Key: "1"}}
+	for _, n := range []int{1, 100} {
+		b.Run(fmt.Sprintf("batch=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				MapReduce(input, manyEmitsMap, IdentityReduce, WithEmitBatch(n))
+			}
+		})
+	}
+}
+
+// runsMap emits 10000 ones in runs of 100 of the same key.
+func runsMap(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+	for i := 0; i < 10000; i++ {
+		collectChl <- MRInput{Key: strconv.Itoa(i / 100), Values: []string{"1"}}
+	}
+	doneChl <- struct{}{}
+}
+
+// addInts adds two integers in their string form.
+func addInts(a, b string) string {
+	x, _ := strconv.Atoi(a)
+	y, _ := strconv.Atoi(b)
+	return strconv.Itoa(x + y)
+}
+
+func TestWithEmitCoalesce(t *testing.T) {
+	input := []MRInput{{Key: "1"}, {Key: "2"}}
+	expected := MapReduce(input, runsMap, SumIntReduce)
+
+	var reducedValues int32
+	reduceFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		atomic.AddInt32(&reducedValues, int32(len(input.Values)))
+		SumIntReduce(input, collectChl, doneChl)
+	}
+	result, stats := MapReduceWithStats(input, runsMap, reduceFunc, WithEmitCoalesce(addInts))
+
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+	// Each mapper sends one record per run, and each key gets one value from each mapper.
+	if stats.MapRecords != 200 || reducedValues != 200 {
+		t.Errorf("Expected <200> map records and reduced values; Got <%d> and <%d>", stats.MapRecords, reducedValues)
+	}
+}
+
+func BenchmarkEmitCoalesce(b *testing.B) {
+	input := []MRInput{{Key: "1"}}
+	for name, opts := range map[string][]Option{"off": nil, "on": {WithEmitCoalesce(addInts)}} {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				MapReduce(input, runsMap, SumIntReduce, opts...)
+			}
+		})
+	}
+}
+
+func TestMapReduceGroupKey(t *testing.T) {
+	input := []MRInput{
+		{Key: "1", Values: []string{"web1.example.com"}},
+		{Key: "2", Values: []string{"web2.example.com"}},
+		{Key: "3", Values: []string{"db.example.org"}},
+	}
+	hostMap := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		collectChl <- MRInput{Key: input.Values[0], Values: []string{input.Key}}
+		doneChl <- struct{}{}
+	}
+	domain := func(kv MRInput) string { return kv.Key[strings.Index(kv.Key, ".")+1:] }
+	// Emits the hosts seen for the domain, which are only available as source keys.
+	hostsReduce := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		hosts := append([]string(nil), input.SourceKeys()...)
+		sort.Strings(hosts)
+		collectChl <- MRInput{Key: input.Key, Values: hosts}
+		doneChl <- struct{}{}
+	}
+
+	result := MapReduce(input, hostMap, hostsReduce, WithGroupKey(domain))
+
+	expected := map[string][]string{
+		"example.com": {"web1.example.com", "web2.example.com"},
+		"example.org": {"db.example.org"},
+	}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
+
+func TestMapReduceInto(t *testing.T) {
+	countWords := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		for _, word := range strings.Fields(input.Values[0]) {
+			EmitInt(collectChl, word, 1)
+		}
+		doneChl <- struct{}{}
+	}
+	first := []MRInput{{Key: "line1", Values: []string{"the dog and the cat"}}}
+	second := []MRInput{{Key: "line2", Values: []string{"the bird"}}, {Key: "line3", Values: []string{"a dog"}}}
+
+	existing := MapReduce(first, countWords, SumIntReduce)
+	before := copyResult(existing)
+	result := MapReduceInto(existing, second, countWords, SumIntReduce)
+
+	expected := MapReduce(append(first, second...), countWords, SumIntReduce)
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+	if !reflect.DeepEqual(before, existing) {
+		t.Errorf("Expected the existing result to be left alone; Got <%v>", existing)
+	}
+}
+
+func TestMapReduceMaxValuesPerKey(t *testing.T) {
+	input := []MRInput{
+		{Key: "line1", Values: []string{"the the the dog"}},
+		{Key: "line2", Values: []string{"the the cat"}},
+	}
+
+	result, warnings := MapReduceWithWarnings(input, wordMap, countReduce, WithMaxValuesPerKey(3))
+
+	expected := map[string][]string{"the": {"3"}, "dog": {"1"}, "cat": {"1"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], `"the"`) {
+		t.Errorf("Expected one truncation warning for <the>; Got <%v>", warnings)
+	}
+}
+
+func TestMapReduceIntoMaxValuesPerKey(t *testing.T) {
+	// The existing result already holds more values for k than the limit allows.
+	existing := map[string][]string{"k": {"1", "1", "1"}}
+	input := []MRInput{{Key: "line1", Values: []string{"k"}}}
+
+	result := MapReduceInto(existing, input, wordMap, countReduce, WithMaxValuesPerKey(2))
+
+	expected := map[string][]string{"k": {"2"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
+
+func TestMapReduceInputFilter(t *testing.T) {
+	var mu sync.Mutex
+	var mapped []string
+	mapFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		mu.Lock()
+		mapped = append(mapped, input.Key)
+		mu.Unlock()
+		wordMap(input, collectChl, doneChl)
+	}
+	even := func(input MRInput) bool {
+		n, _ := strconv.Atoi(input.Key)
+		return n%2 == 0
+	}
+
+	result, stats := MapReduceWithStats(numberedInputs(10), mapFunc, countReduce, WithInputFilter(even))
+
+	sort.Strings(mapped)
+	if expected := []string{"0", "2", "4", "6", "8"}; !reflect.DeepEqual(expected, mapped) {
+		t.Errorf("Expected only the even inputs to be mapped <%v>; Got <%v>", expected, mapped)
+	}
+	if stats.MapTasks != 5 || len(result) != 5 {
+		t.Errorf("Expected 5 map tasks and results; Got <%d> and <%v>", stats.MapTasks, result)
+	}
+}
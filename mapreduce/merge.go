@@ -0,0 +1,108 @@
+package mapreduce
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+)
+
+// MapReduceSortedMerge reduces the records of sources, each of which must deliver them sorted by key, as shards of a
+// sorted output do, and be closed by its sender once it is done. Rather than grouping the records in a map, it merges
+// the sources, so that only the group being merged is held in memory, and each group goes to a reducer as soon as
+// the merge has moved past its key. A group's values are those of the sources in source order, and within a source
+// in the order they came in. It merges on top of WithShuffler, so the job's other options apply as they would to a
+// job with a Shuffler, which this replaces. If a source turns out not to be sorted, the job fails with an error
+// naming it and its records from then on, as well as those of the other sources, are discarded as they come in.
+func MapReduceSortedMerge(sources []<-chan MRInput, reduceFunc ReduceFunc, opts ...Option) (map[string][]string,
+	error) {
+	merge := &mergeShuffler{sources: sources}
+	return TryMapReduce(nil, IdentityMap, reduceFunc, append(opts, WithShuffler(merge))...)
+}
+
+// mergeShuffler is the Shuffler of MapReduceSortedMerge, whose groups come from merging sorted sources rather than
+// from the map output.
+type mergeShuffler struct {
+	sources []<-chan MRInput
+}
+
+// Add implements Shuffler. MapReduceSortedMerge runs no map tasks, so it is never called.
+func (m *mergeShuffler) Add(kv MRInput) error {
+	return errors.New("mapreduce: map output can't be added to a sorted merge")
+}
+
+// Groups implements Shuffler, calling fn with each of the keys of the sources in order.
+func (m *mergeShuffler) Groups(fn func(group MRInput) error) (err error) {
+	h := make(mergeHeap, 0, len(m.sources))
+	defer func() {
+		// Keep the senders of any sources not read to the end from blocking.
+		for _, head := range h {
+			go func(ch <-chan MRInput) {
+				for range ch {
+				}
+			}(head.ch)
+		}
+	}()
+	for i, ch := range m.sources {
+		if kv, ok := <-ch; ok {
+			h = append(h, &mergeHead{ch: ch, source: i, kv: kv})
+		}
+	}
+	heap.Init(&h)
+
+	var group *MRInput
+	for len(h) > 0 {
+		head := h[0]
+		if group != nil && head.kv.Key != group.Key {
+			if err := fn(*group); err != nil {
+				return err
+			}
+			group = nil
+		}
+		if group == nil {
+			group = &MRInput{Key: head.kv.Key, Values: []string{}}
+		}
+		group.Values = append(group.Values, head.kv.Values...)
+
+		kv, ok := <-head.ch
+		switch {
+		case !ok:
+			heap.Pop(&h)
+		case kv.Key < head.kv.Key:
+			return fmt.Errorf("mapreduce: source %d isn't sorted by key: %q came after %q", head.source, kv.Key,
+				head.kv.Key)
+		default:
+			head.kv = kv
+			heap.Fix(&h, 0)
+		}
+	}
+	if group != nil {
+		return fn(*group)
+	}
+	return nil
+}
+
+// mergeHead is the record a source of a sorted merge is at.
+type mergeHead struct {
+	ch     <-chan MRInput
+	source int
+	kv     MRInput
+}
+
+// mergeHeap orders sources by the keys they are at, and those at the same key by source, for merging.
+type mergeHeap []*mergeHead
+
+func (h mergeHeap) Len() int { return len(h) }
+func (h mergeHeap) Less(a, b int) bool {
+	if h[a].kv.Key != h[b].kv.Key {
+		return h[a].kv.Key < h[b].kv.Key
+	}
+	return h[a].source < h[b].source
+}
+func (h mergeHeap) Swap(a, b int)       { h[a], h[b] = h[b], h[a] }
+func (h *mergeHeap) Push(x interface{}) { *h = append(*h, x.(*mergeHead)) }
+func (h *mergeHeap) Pop() interface{} {
+	old := *h
+	head := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return head
+}
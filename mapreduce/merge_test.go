@@ -0,0 +1,55 @@
+package mapreduce
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sortedSource returns a closed channel holding kvs, as a source for MapReduceSortedMerge.
+func sortedSource(kvs ...MRInput) <-chan MRInput {
+	ch := make(chan MRInput, len(kvs))
+	for _, kv := range kvs {
+		ch <- kv
+	}
+	close(ch)
+	return ch
+}
+
+func TestMapReduceSortedMerge(t *testing.T) {
+	left := sortedSource(
+		MRInput{Key: "apple", Values: []string{"1"}},
+		MRInput{Key: "cherry", Values: []string{"2"}},
+		MRInput{Key: "cherry", Values: []string{"3"}},
+		MRInput{Key: "plum", Values: []string{"4"}},
+	)
+	right := sortedSource(
+		MRInput{Key: "banana", Values: []string{"5"}},
+		MRInput{Key: "cherry", Values: []string{"6"}},
+		MRInput{Key: "plum", Values: []string{"7", "8"}},
+	)
+
+	result, err := MapReduceSortedMerge([]<-chan MRInput{left, right}, IdentityReduce)
+
+	if err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	// Values come in source order, and in order within a source.
+	expected := map[string][]string{
+		"apple": {"1"}, "banana": {"5"}, "cherry": {"2", "3", "6"}, "plum": {"4", "7", "8"},
+	}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
+
+func TestMapReduceSortedMergeUnsorted(t *testing.T) {
+	sorted := sortedSource(MRInput{Key: "a", Values: []string{"1"}}, MRInput{Key: "z", Values: []string{"2"}})
+	unsorted := sortedSource(MRInput{Key: "b", Values: []string{"3"}}, MRInput{Key: "a", Values: []string{"4"}})
+
+	_, err := MapReduceSortedMerge([]<-chan MRInput{sorted, unsorted}, SumIntReduce)
+
+	if err == nil || !strings.Contains(err.Error(), "source 1 isn't sorted") {
+		t.Errorf("Expected an error naming source 1; Got <%v>", err)
+	}
+}
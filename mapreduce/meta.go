@@ -0,0 +1,44 @@
+package mapreduce
+
+import "context"
+
+// ValueMeta returns the Meta of the record that each of a reducer input's values was emitted in, in the same order
+// as Values, with nil for values emitted without any. It returns nil if none of the input's values had any. A
+// reducer that emits its input as it is, such as IdentityReduce, keeps each value's Meta.
+func (kv MRInput) ValueMeta() []map[string]string {
+	return kv.valueMeta
+}
+
+// MapReduceWithMeta is like MapReduce, but also returns the Meta of the record that each of the result's values was
+// emitted in by the reducers, in the same order as the values, with nil for values emitted without any. Keys none of
+// whose values had any are left out. Options that drop or reorder the result's values, such as WithValueDedup and
+// WithFinalizer, don't keep the Meta in step, so they shouldn't be combined with it.
+func MapReduceWithMeta(input []MRInput, mapFunc MapFunc, reduceFunc ReduceFunc, opts ...Option) (
+	result map[string][]string, meta map[string][]map[string]string) {
+	out := run(context.TODO(), input, []MapFunc{mapFunc}, reduceFunc, newConfig(opts))
+	out.mustSucceed()
+	return out.result, out.meta
+}
+
+// collectMeta records the Meta of result's values, which follow the first before values of key: each value's own, if
+// result is a reducer input being passed on, and otherwise result's Meta. Values that came without any get nil.
+func (j *job) collectMeta(key string, before int, result MRInput) {
+	if j.meta == nil {
+		j.meta = make(map[string][]map[string]string)
+	}
+	m := j.meta[key]
+	for len(m) < before {
+		m = append(m, nil)
+	}
+	for i := range result.Values {
+		switch {
+		case result.valueMeta == nil:
+			m = append(m, result.Meta)
+		case i < len(result.valueMeta):
+			m = append(m, result.valueMeta[i])
+		default:
+			m = append(m, nil)
+		}
+	}
+	j.meta[key] = m
+}
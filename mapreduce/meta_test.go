@@ -0,0 +1,61 @@
+package mapreduce
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// provenanceInputs are lines of text, each with the file and line it came from as its Meta.
+var provenanceInputs = []MRInput{
+	{Key: "a.txt:1", Values: []string{"the dog"}, Meta: map[string]string{"file": "a.txt", "line": "1"}},
+	{Key: "b.txt:7", Values: []string{"the cat"}, Meta: map[string]string{"file": "b.txt", "line": "7"}},
+}
+
+// provenanceMap is wordMap passing on each input's Meta.
+func provenanceMap(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+	for _, word := range strings.Fields(input.Values[0]) {
+		collectChl <- MRInput{Key: word, Values: []string{"1"}, Meta: input.Meta}
+	}
+	doneChl <- struct{}{}
+}
+
+func TestMapReduceWithMeta(t *testing.T) {
+	_, meta := MapReduceWithMeta(provenanceInputs, provenanceMap, IdentityReduce)
+
+	the := meta["the"]
+	sort.Slice(the, func(a, b int) bool { return the[a]["file"] < the[b]["file"] })
+	expected := []map[string]string{provenanceInputs[0].Meta, provenanceInputs[1].Meta}
+	if !reflect.DeepEqual(expected, the) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, the)
+	}
+	if !reflect.DeepEqual([]map[string]string{provenanceInputs[1].Meta}, meta["cat"]) {
+		t.Errorf("Expected <%v>; Got <%v>", provenanceInputs[1].Meta, meta["cat"])
+	}
+}
+
+func TestValueMeta(t *testing.T) {
+	// Counts each word and records where its occurrences came from.
+	reduceFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		var sources []string
+		for _, m := range input.ValueMeta() {
+			sources = append(sources, m["file"]+":"+m["line"])
+		}
+		sort.Strings(sources)
+		collectChl <- MRInput{Key: input.Key, Values: []string{strconv.Itoa(len(input.Values))},
+			Meta: map[string]string{"sources": strings.Join(sources, ",")}}
+		doneChl <- struct{}{}
+	}
+
+	result, meta := MapReduceWithMeta(provenanceInputs, provenanceMap, reduceFunc)
+
+	if !reflect.DeepEqual([]string{"2"}, result["the"]) {
+		t.Errorf("Expected <[2]>; Got <%v>", result["the"])
+	}
+	expected := []map[string]string{{"sources": "a.txt:1,b.txt:7"}}
+	if !reflect.DeepEqual(expected, meta["the"]) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, meta["the"])
+	}
+}
@@ -0,0 +1,50 @@
+package mapreduce
+
+import "fmt"
+
+// EmitTo sends key with values to the named stream of a MapReduceNamed job, to be reduced by that stream's reducer.
+func EmitTo(collectChl chan MRInput, stream, key string, values ...string) {
+	collectChl <- MRInput{Key: CompositeKey(stream, key), Values: values}
+}
+
+// MapReduceNamed is like MapReduce, but for a mapper that feeds several reducers: it emits each record to one of a
+// number of named streams with EmitTo, and each stream is reduced by the reducer that reducers has for its name.
+// The result is returned per stream, with an entry for every stream in reducers, even one nothing was emitted to.
+// Records sent to a stream missing from reducers, or without EmitTo, fail the job. The streams share the job's map
+// and reduce phases, so opts apply to all of them, but WithKeyNormalizer and WithGroupKey see keys that EmitTo has
+// qualified with the stream name, and so shouldn't be combined with it.
+func MapReduceNamed(input []MRInput, mapFunc MapFunc, reducers map[string]ReduceFunc,
+	opts ...Option) map[string]map[string][]string {
+	// Runs the stream's reducer on the unqualified key, qualifying the keys it emits in turn.
+	reduce := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		parts := SplitKey(input.Key)
+		var reduceFunc ReduceFunc
+		if len(parts) == 2 {
+			reduceFunc = reducers[parts[0]]
+		}
+		if reduceFunc == nil {
+			emitError(collectChl, fmt.Errorf("mapreduce: key %q wasn't emitted to a named stream with a reducer", input.Key))
+			doneChl <- struct{}{}
+			return
+		}
+		stream := parts[0]
+		input.Key = parts[1]
+		intercept(reducePhase, reduceFunc, input, func(kv MRInput) {
+			if kv.ctl == nil {
+				kv.Key = CompositeKey(stream, kv.Key)
+			}
+			collectChl <- kv
+		})
+		doneChl <- struct{}{}
+	}
+
+	results := make(map[string]map[string][]string, len(reducers))
+	for stream := range reducers {
+		results[stream] = make(map[string][]string)
+	}
+	for key, values := range MapReduce(input, mapFunc, reduce, opts...) {
+		parts := SplitKey(key)
+		results[parts[0]][parts[1]] = values
+	}
+	return results
+}
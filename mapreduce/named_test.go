@@ -0,0 +1,52 @@
+package mapreduce
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestMapReduceNamed(t *testing.T) {
+	input := []MRInput{
+		{Key: "line1", Values: []string{"the dog"}},
+		{Key: "line2", Values: []string{"the horse"}},
+	}
+	// Counts each word in one stream, and lists the words of each length in the other.
+	namedMap := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		for _, word := range strings.Fields(input.Values[0]) {
+			EmitTo(collectChl, "counts", word, "1")
+			EmitTo(collectChl, "lengths", strconv.Itoa(len(word)), word)
+		}
+		doneChl <- struct{}{}
+	}
+	sortedReduce := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		sort.Strings(input.Values)
+		collectChl <- input
+		doneChl <- struct{}{}
+	}
+	reducers := map[string]ReduceFunc{"counts": SumIntReduce, "lengths": sortedReduce, "unused": CountReduce}
+
+	result := MapReduceNamed(input, namedMap, reducers)
+
+	expected := map[string]map[string][]string{
+		"counts":  {"the": {"2"}, "dog": {"1"}, "horse": {"1"}},
+		"lengths": {"3": {"dog", "the", "the"}, "5": {"horse"}},
+		"unused":  {},
+	}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
+
+func TestMapReduceNamedUnknownStream(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected a panic for a stream without a reducer")
+		}
+	}()
+	input := []MRInput{{Key: "line1", Values: []string{"the dog"}}}
+
+	MapReduceNamed(input, wordMap, map[string]ReduceFunc{"counts": countReduce})
+}
@@ -0,0 +1,539 @@
+package mapreduce
+
+import (
+	"io"
+	"time"
+)
+
+// Option configures optional behavior of a map-reduce run.
+type Option func(*config)
+
+// config holds the settings assembled from a set of Options.
+type config struct {
+	// reduceMemo enables per-key memoization of reduce results across Iterate iterations.
+	reduceMemo bool
+	// divergenceLimit is how many consecutive growing iterations Iterate allows.
+	divergenceLimit int
+	// lazyReduceInput feeds reducers directly from the intermediate map instead of a slice copy of it.
+	lazyReduceInput bool
+	// inputFilter, if set, decides which inputs are mapped at all.
+	inputFilter func(MRInput) bool
+	// keyNormalizer, if set, is applied to every emitted key before it is grouped.
+	keyNormalizer func(string) string
+	// groupKeyFunc, if set, decides which group each map output record belongs to.
+	groupKeyFunc func(MRInput) string
+	// maxValuesPerKey, if positive, is the most values the collector keeps for any one key.
+	maxValuesPerKey int
+	// barrier, if set, is called once between the map and reduce phases.
+	barrier func(intermediate map[string][]string)
+	// broadcast, if set, builds a value from the intermediate results for every reducer to share.
+	broadcast func(intermediate map[string][]string) interface{}
+	// emptyReduce is what the result holds for keys that their reducers emitted nothing under.
+	emptyReduce EmptyReducePolicy
+	// valueEqual, if set, is the equality by which duplicate values are removed from each key of the result.
+	valueEqual func(a, b string) bool
+	// valueSet makes each key of the result hold its unique values, sorted.
+	valueSet bool
+	// outputDir, if set, is the directory that each key's reduce output is written to a file in, named by outputName.
+	outputDir  string
+	outputName func(key string) string
+	// finalizer, if set, transforms the complete reduce output into the job's result.
+	finalizer func(result map[string][]string) map[string][]string
+	// resultValidator, if set, checks the job's result, its error becoming the job's.
+	resultValidator func(result map[string][]string) error
+	// detectKeyCollision makes it an error for two reduce tasks to emit the same key.
+	detectKeyCollision bool
+	// mapExecutor and reduceExecutor run the tasks of the map and reduce phases.
+	mapExecutor    Executor
+	reduceExecutor Executor
+	// sequential makes the job run its tasks one at a time, in a fixed order.
+	sequential bool
+	// skipFailedInputs makes the job drop the output of a map function that fails, rather than fail itself.
+	skipFailedInputs bool
+	// panicPolicy is how the job responds to a task that panics.
+	panicPolicy PanicPolicy
+	// emitBatch is how many map output records are sent to the collector at a time.
+	emitBatch int
+	// dryRun makes the job only try its map functions on its first input.
+	dryRun bool
+	// insertionOrder makes the job record the order in which keys first appear in the map output.
+	insertionOrder bool
+	// profilerLabels makes tasks run under pprof labels naming their phase.
+	profilerLabels bool
+	// tracer, if set, traces the job and each of its tasks.
+	tracer Tracer
+	// outputBuffer is the capacity of a streaming job's output channel.
+	outputBuffer int
+	// dropPolicy is what a streaming job does with output that doesn't fit in its output channel.
+	dropPolicy DropPolicy
+	// shuffler, if set, groups the map output in place of the intermediate map.
+	shuffler Shuffler
+	// taskTimeout, if positive, is how long a task may run before it is abandoned.
+	taskTimeout time.Duration
+	// stallTimeout, if positive, is how long the collector waits for anything from running tasks before giving up.
+	stallTimeout time.Duration
+	// reduceTree, if set, combines each key's values pairwise before they are reduced.
+	reduceTree func(a, b string) string
+	// combineIdentity, if set, holds the identity value of the combine functions of reduceTree and emitCoalesce.
+	combineIdentity []string
+	// collectBuffer is the capacity of the channel that map and reduce functions send to the collector on.
+	collectBuffer int
+	// sink, if set, receives the reduce output in place of the result map.
+	sink Sink
+	// balancedReduce spreads the reduce work evenly over lanes of reducers run one after another.
+	balancedReduce bool
+	// partialReduce is what becomes of unfinished reducers' output when the job is cancelled.
+	partialReduce PartialReducePolicy
+	// emitCoalesce, if set, combines the values of each mapper's consecutive emits of a key into one.
+	emitCoalesce func(a, b string) string
+	// regroupKey and regroupReduce, if set, make up a second reduce stage, over the reduce output regrouped by
+	// regroupKey.
+	regroupKey    func(key string) string
+	regroupReduce ReduceFunc
+	// mapRetries is how many more times a map function that fails on an input is run on it, waiting between
+	// attempts as retryBackoff says.
+	mapRetries   int
+	retryBackoff retryBackoff
+	// live, if set, gets a copy of the reduce output as it is collected, for JobHandle.Snapshot.
+	live *liveResult
+	// recorder, if set, gets a record of every record and done signal the collector receives.
+	recorder io.Writer
+	// maxErrors is how many errors the job tolerates before it is aborted, or -1 for any number.
+	maxErrors int
+	// pause, if set, holds back the job's tasks while it is paused, for JobHandle.Pause.
+	pause *pauseGate
+	// sample, if set, is the sample of the inputs that master maps, under WithInputSampleRate.
+	sample *inputSample
+	// keyTTL, if greater than 0, is how long a key of the result lasts without being touched before it is evicted.
+	keyTTL time.Duration
+	// collectors is the number of goroutines that receive from collectChl, if more than 1.
+	collectors int
+	// memorySpill, if greater than 0, is the percentage of the memory available at which the map output is spilled
+	// to disk.
+	memorySpill int
+	// reducerRouter, if set, chooses the reduce function of each key, in place of the job's own unless it returns nil.
+	reducerRouter func(key string) ReduceFunc
+	// clock is the source of time for timeouts. Tests substitute their own.
+	clock clock
+	// collectStep, if set, is called by the collector before it waits for each message. Tests only.
+	collectStep func()
+}
+
+// newConfig applies opts to a config holding the defaults.
+func newConfig(opts []Option) *config {
+	executor := defaultExecutor()
+	cfg := &config{mapExecutor: executor, reduceExecutor: executor, clock: realClock{}, maxErrors: -1}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.pause != nil {
+		cfg.mapExecutor = pausableExecutor{Executor: cfg.mapExecutor, gate: cfg.pause}
+		cfg.reduceExecutor = pausableExecutor{Executor: cfg.reduceExecutor, gate: cfg.pause}
+	}
+	return cfg
+}
+
+// WithReduceMemo makes Iterate skip re-running the reducer for a key whose input values are identical to the
+// previous iteration, returning the output cached from that iteration instead. Values are compared as a hash of the
+// sorted value slice, so the reducer must not depend on the order of its input values (which map-reduce does not
+// guarantee anyway). It has no effect outside of Iterate.
+func WithReduceMemo() Option {
+	return func(cfg *config) {
+		cfg.reduceMemo = true
+	}
+}
+
+// WithDivergenceLimit makes Iterate give up, with an error wrapping ErrDivergence, once the number of keys in its
+// result has grown in n consecutive iterations, which is the usual sign of a reducer that keeps emitting new keys and
+// would never converge. Computations that legitimately grow for a while, such as a reachability search, need n to be
+// larger than the number of iterations they grow for. It has no effect outside of Iterate.
+func WithDivergenceLimit(n int) Option {
+	return func(cfg *config) {
+		cfg.divergenceLimit = n
+	}
+}
+
+// WithLazyReduceInput makes master start reducers straight from the map of intermediate results, rather than first
+// copying it into a slice. This avoids briefly holding two copies of the intermediate data between the map and
+// reduce phases, which matters when it is large.
+func WithLazyReduceInput() Option {
+	return func(cfg *config) {
+		cfg.lazyReduceInput = true
+	}
+}
+
+// WithInputFilter has master map only the inputs that keep returns true for, dropping the rest before any map task
+// is started for them, which is cheaper than having the mapper ignore them. For inputs batched by BatchInputs, keep
+// is called on each input in the batch, and the batch's task still runs even if keep drops all of them.
+func WithInputFilter(keep func(MRInput) bool) Option {
+	return func(cfg *config) {
+		cfg.inputFilter = keep
+	}
+}
+
+// WithKeyNormalizer has the collector group emitted records by normalize(key) rather than by key, e.g. so that "Foo",
+// "foo", and " foo " all land in one group. It is applied to the output of both the map and reduce phases, so
+// reducers see normalized keys and the result only contains normalized keys.
+func WithKeyNormalizer(normalize func(key string) string) Option {
+	return func(cfg *config) {
+		cfg.keyNormalizer = normalize
+	}
+}
+
+// WithGroupKey has the collector group the map output by groupKey(record) rather than by the record's key, so that
+// what a mapper emits and how it is grouped can differ, e.g. grouping full host names by their domain. Reducers get
+// the group's key as their input's Key, and the key each value was emitted with from its SourceKeys method. It takes
+// the place of any WithKeyNormalizer function for the map output, but not for the reduce output, which is grouped by
+// key as usual.
+func WithGroupKey(groupKey func(MRInput) string) Option {
+	return func(cfg *config) {
+		cfg.groupKeyFunc = groupKey
+	}
+}
+
+// WithMaxValuesPerKey has the collector keep no more than the first n values it receives for any one key, in both
+// phases, discarding the rest with a warning for each key it truncates, so that one runaway key can't take all of
+// the job's memory. Which values are kept depends on the order mappers deliver them in, so a key that reaches the
+// limit may be reduced differently from run to run. The existing values of a key given to MapReduceInto count toward
+// the limit too, and are truncated the same way.
+func WithMaxValuesPerKey(n int) Option {
+	return func(cfg *config) {
+		cfg.maxValuesPerKey = n
+	}
+}
+
+// WithBarrier has master call barrier exactly once, after every mapper has finished and before any reducer starts,
+// with the complete intermediate results. It is a hook for logging, metrics, checkpointing or validation between the
+// phases. The map is the one the reducers will be fed from, so barrier must treat it as read-only.
+func WithBarrier(barrier func(intermediate map[string][]string)) Option {
+	return func(cfg *config) {
+		cfg.barrier = barrier
+	}
+}
+
+// WithReduceBroadcast has master call build once, between the map and reduce phases, with the complete intermediate
+// results, and make what it returns available to every reducer through its input's Broadcast method. It suits small
+// lookup tables derived from the map output, such as a total to normalize against. build must treat the map as
+// read-only, and reducers must treat the value as read-only, as they all share it.
+func WithReduceBroadcast(build func(intermediate map[string][]string) interface{}) Option {
+	return func(cfg *config) {
+		cfg.broadcast = build
+	}
+}
+
+// WithEmptyReducePolicy sets what the job's result holds for a key that no reducer emitted anything under, such as
+// a key whose reducer filtered it out; see EmptyReducePolicy. Reducers that emit under keys other than their input's
+// don't count as emitting for their input key. It has no effect on jobs started with MapReduceStream.
+func WithEmptyReducePolicy(p EmptyReducePolicy) Option {
+	return func(cfg *config) {
+		cfg.emptyReduce = p
+	}
+}
+
+// WithValueDedup removes duplicate values from each key of the job's result, keeping the first of each set of values
+// that equal considers the same, e.g. strings.EqualFold for a case-insensitive result. As an arbitrary equality can't
+// be hashed, every value is compared with those kept before it, which costs O(n²) comparisons for a key with n
+// values; for keys with many values, have the reducer normalize them and dedup with a map instead. It is applied
+// before any finalizer, and has no effect on jobs started with MapReduceStream.
+func WithValueDedup(equal func(a, b string) bool) Option {
+	return func(cfg *config) {
+		cfg.valueEqual = equal
+	}
+}
+
+// WithValueSet makes each key of the job's result hold the set of values the reducers emitted for it: each value
+// once, sorted in increasing byte order, which for UTF-8 is code point order. Duplicates are dropped as the reduce
+// output is collected, by looking them up in a set for the key, so unlike WithValueDedup it costs O(1) per value, and
+// duplicates never take up space in the result. It applies to the output of RegroupReduce too, and before
+// WithValueDedup and any finalizer, but has no effect on jobs started with MapReduceStream. Like options that drop
+// values, it doesn't keep the values' weights or Meta in step.
+func WithValueSet() Option {
+	return func(cfg *config) {
+		cfg.valueSet = true
+	}
+}
+
+// WithPerKeyOutput has the job write each key of the reduce output to a file of its own in dir, one value per line,
+// once the reduce phase is complete. The file for a key is named by nameFunc, which must turn keys into names that
+// are safe for the filesystem and distinct for distinct keys; if nameFunc is nil, keys are escaped as URL path
+// segments. dir is created if need be. A name that isn't a plain file name, such as one containing a path separator,
+// or a file that can't be written, fails the job for that key. The result is returned as usual, before any
+// finalizer is applied to it.
+func WithPerKeyOutput(dir string, nameFunc func(key string) string) Option {
+	return func(cfg *config) {
+		cfg.outputDir = dir
+		cfg.outputName = nameFunc
+	}
+}
+
+// WithFinalizer has master pass the complete output of the reduce phase to finalize, exactly once, and return what
+// it returns as the job's result. It suits trivial global rollups, such as a grand total, that would otherwise need a
+// second map-reduce. It isn't called if the job is cancelled, nor for jobs started with MapReduceStream.
+func WithFinalizer(finalize func(result map[string][]string) map[string][]string) Option {
+	return func(cfg *config) {
+		cfg.finalizer = finalize
+	}
+}
+
+// WithResultValidator has master check the job's result with validate, for post-conditions such as every key having
+// exactly one value, once the result is otherwise complete, after any finalizer. If validate returns an error, the
+// job fails with it, along with the result as it stands. It isn't called if the job is cancelled or has already
+// failed, nor for jobs started with MapReduceStream.
+func WithResultValidator(validate func(result map[string][]string) error) Option {
+	return func(cfg *config) {
+		cfg.resultValidator = validate
+	}
+}
+
+// WithDetectKeyCollision makes it an error, wrapping ErrKeyCollision, for reducers of two different keys to emit the
+// same output key. By default their values are silently appended together, which for most reductions means that
+// the keys weren't routed consistently.
+func WithDetectKeyCollision() Option {
+	return func(cfg *config) {
+		cfg.detectKeyCollision = true
+	}
+}
+
+// WithMapExecutor has the map phase run its tasks on e rather than on a goroutine apiece or the global Pool.
+func WithMapExecutor(e Executor) Option {
+	return func(cfg *config) {
+		cfg.mapExecutor = e
+	}
+}
+
+// WithReduceExecutor has the reduce phase run its tasks on e rather than on a goroutine apiece or the global Pool.
+func WithReduceExecutor(e Executor) Option {
+	return func(cfg *config) {
+		cfg.reduceExecutor = e
+	}
+}
+
+// WithSequential makes the job deterministic, for tests and fuzzing: every task runs to completion before the next
+// one starts, map tasks in the order of the job's input and map functions, and reduce tasks in key order, so the
+// collector receives the same records in the same order on every run. With a reducer that depends on the order of
+// its values, it gives the same result as a single-threaded implementation would. It replaces the job's executors,
+// and takes precedence over WithLazyReduceInput and WithBalancedReduce. Tasks still run on goroutines other than the
+// caller's.
+func WithSequential() Option {
+	return func(cfg *config) {
+		cfg.sequential = true
+		cfg.mapExecutor, cfg.reduceExecutor = sequentialExecutor{}, sequentialExecutor{}
+	}
+}
+
+// WithPanicPolicy sets how the job responds to a map or reduce function that panics; see PanicPolicy.
+func WithPanicPolicy(p PanicPolicy) Option {
+	return func(cfg *config) {
+		cfg.panicPolicy = p
+	}
+}
+
+// WithMaxErrors has the job tolerate up to n errors from its tasks, such as panics under PanicError or parse errors
+// from SumIntReduce, and abort as soon as there are more, cancelling the tasks still to run as PanicFail does: a
+// middle ground between failing on the first error and running every task whatever happens. The job then returns
+// the errors reported so far, followed by one saying it was aborted, and its partial result, which is empty if it
+// was aborted in the map phase. A job that has no more than n errors runs to completion, and returns them as usual.
+// An n of 0 aborts the job on its first error.
+func WithMaxErrors(n int) Option {
+	return func(cfg *config) {
+		cfg.maxErrors = n
+	}
+}
+
+// WithSkipFailedInputs isolates the job from inputs its map function fails on, by panicking or via a built-in error.
+// Such an input's output is discarded, the input itself is recorded in Stats.FailedInputs, and the job carries on
+// without it and without an error. Unlike a retry, this gives up on the input straight away. Each input of a batch
+// is isolated separately. To keep a failed input's partial output out of the job, every input's output is held until
+// its map function finishes, so mappers that emit a lot per input take correspondingly more memory.
+func WithSkipFailedInputs() Option {
+	return func(cfg *config) {
+		cfg.skipFailedInputs = true
+	}
+}
+
+// WithEmitBatch has each map task send its output to the collector n records at a time, in the order they were
+// emitted, rather than one by one, which cuts the cost of synchronizing with the collector for mappers that emit
+// many small records. A task's last batch is sent when it finishes, so a batch holds the output of a single task.
+func WithEmitBatch(n int) Option {
+	return func(cfg *config) {
+		cfg.emitBatch = n
+	}
+}
+
+// WithDryRun makes the job a cheap check of its map functions rather than a run: each of them is run once, on the
+// first input alone, and the job fails if any of them panics or reports an error, emits a record without a key, or
+// doesn't signal done within ten seconds. Nothing is reduced and the result is always empty, so it is meant for
+// TryMapReduce and the like, whose error reports what the dry run found.
+func WithDryRun() Option {
+	return func(cfg *config) {
+		cfg.dryRun = true
+	}
+}
+
+// WithInsertionOrder makes MapReduceOrdered return keys in the order they first appear in the map output, rather than
+// sorted. That order is by input, then by mapper (for MapReduceMultiMap), then by the order the mapper emitted them,
+// so it doesn't depend on how the job's goroutines happened to be scheduled. Keys that only reducers emit follow the
+// rest, sorted.
+func WithInsertionOrder() Option {
+	return func(cfg *config) {
+		cfg.insertionOrder = true
+	}
+}
+
+// WithTracer has the job record a span for itself and a child span for each map and reduce task, with the task's
+// input key as an attribute. Without it, tracing costs nothing.
+func WithTracer(t Tracer) Option {
+	return func(cfg *config) {
+		cfg.tracer = t
+	}
+}
+
+// WithProfilerLabels runs every task under the pprof label "phase", set to "map" or "reduce", so that goroutine and
+// CPU profiles tell mappers and reducers apart. Goroutines the tasks start inherit the label.
+func WithProfilerLabels() Option {
+	return func(cfg *config) {
+		cfg.profilerLabels = true
+	}
+}
+
+// WithOutputBuffer sets the capacity of the output channel of a job started with MapReduceStream. It is unbuffered
+// by default.
+func WithOutputBuffer(n int) Option {
+	return func(cfg *config) {
+		cfg.outputBuffer = n
+	}
+}
+
+// WithDropPolicy sets what a job started with MapReduceStream does when its output channel is full. Any policy other
+// than Block intentionally loses data whenever the consumer falls behind, in exchange for never stalling the job;
+// Stats.DroppedRecords reports how much was lost.
+func WithDropPolicy(p DropPolicy) Option {
+	return func(cfg *config) {
+		cfg.dropPolicy = p
+	}
+}
+
+// WithShuffler has the job group its map output with s rather than in memory, and reduce the groups as s produces
+// them, so that a job can reduce more intermediate data than fits in memory; NewDiskShuffler returns a Shuffler that
+// sorts the data on disk. To also bound the number of groups held at once, reduce on a Pool (see
+// WithReduceExecutor). As no intermediate map is built, options that look at it as a whole, such as WithBarrier,
+// WithReduceBroadcast and WithEmptyReducePolicy, see it empty, as does MapReduceWithIntermediate. The existing result
+// given to MapReduceInto is added to s before the map output. A Shuffler handles one job at a time.
+func WithShuffler(s Shuffler) Option {
+	return func(cfg *config) {
+		cfg.shuffler = s
+	}
+}
+
+// WithTaskTimeout fails the job if any of its map or reduce tasks doesn't signal done within d, naming the task's
+// key in the error. The task is then abandoned: it goes on running in the background, but whatever it emits from
+// then on is discarded. Within the time allowed, its output is relayed to the collector as usual.
+func WithTaskTimeout(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.taskTimeout = d
+	}
+}
+
+// WithStallTimeout fails the job if, while it has map or reduce tasks running, it goes d without receiving anything
+// from them: neither output nor a done signal. This catches map and reduce functions that deadlock, and would
+// otherwise hang the job. The job then stops as it does when cancelled, abandoning the tasks still running, and
+// returns an error saying which phase stalled; the result is partial as in MapReduceContext. Unlike
+// WithTaskTimeout, it doesn't limit how long a task that keeps emitting may take.
+func WithStallTimeout(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.stallTimeout = d
+	}
+}
+
+// WithReduceTree has each key's values combined into one with combine before the reducer sees them, which then gets
+// the key with just the combined value. The values are combined pairwise in a balanced tree, each level of which is
+// spread across goroutines, so a key with many values is combined in parallel, in a number of steps that grows with
+// the logarithm of their number rather than linearly. combine must be associative, as the grouping of the values
+// varies with their number; neighbouring values are combined, so it needn't be commutative. It must be safe to call
+// from several goroutines at once. Paired with IdentityReduce, the result is just the combined value of each key.
+func WithReduceTree(combine func(a, b string) string) Option {
+	return func(cfg *config) {
+		cfg.reduceTree = combine
+	}
+}
+
+// WithCombineIdentity gives the identity value of the combine function of WithReduceTree and WithEmitCoalesce, the
+// value that leaves any other unchanged when combined with it, such as "0" for addition or "" for concatenation.
+// Together with an associative combine, that makes a monoid, which is the contract a combiner has to keep for the
+// grouping of the values not to matter: with it, no values combine to the identity just as one value combines to
+// itself. So a key with no values reaches the reducer under WithReduceTree with the identity as its only value,
+// rather than with none, and a run of a mapper's emits of a key without values is coalesced into a record with the
+// identity under WithEmitCoalesce. It has no effect without one of them.
+func WithCombineIdentity(identity string) Option {
+	return func(cfg *config) {
+		cfg.combineIdentity = []string{identity}
+	}
+}
+
+// WithCollectBuffer sets the capacity of the channel that map and reduce functions send their output to the
+// collector on, which is unbuffered by default. A buffer lets them run ahead of the collector in bursts; see
+// Stats.EmitBlocks and Stats.PeakCollectBacklog for how much that is needed.
+func WithCollectBuffer(n int) Option {
+	return func(cfg *config) {
+		cfg.collectBuffer = n
+	}
+}
+
+// WithSink has the job deliver its reduce output to s instead of gathering it into the map it returns, which is then
+// empty; see Sink. Options that work on the result map, such as WithValueDedup, WithPerKeyOutput, WithFinalizer and
+// WithEmptyReducePolicy, have no effect then. It doesn't apply to MapReduceStream, whose output goes to its channel.
+func WithSink(s Sink) Option {
+	return func(cfg *config) {
+		cfg.sink = s
+	}
+}
+
+// WithBalancedReduce evens out the reduce work on skewed data. After the map phase the keys are sorted by their
+// number of values, and each in turn is assigned to whichever of the reduce workers has the fewest values assigned
+// so far. Each worker then runs the reducers of its keys one after another, largest first, so no worker is left with
+// a disproportionate share to finish after the others. There are as many workers as the reduce executor's
+// goroutines, if it is a Pool, and otherwise GOMAXPROCS. It has no effect under WithShuffler or WithSequential.
+func WithBalancedReduce() Option {
+	return func(cfg *config) {
+		cfg.balancedReduce = true
+	}
+}
+
+// WithPartialReduceOnCancel sets what becomes of the output of reducers that haven't finished when the job is
+// cancelled: see PartialReducePolicy. The default, Discard, holds each reducer's output back until it has finished,
+// which takes memory for reducers that emit a lot; Include sends it on as it is emitted.
+func WithPartialReduceOnCancel(p PartialReducePolicy) Option {
+	return func(cfg *config) {
+		cfg.partialReduce = p
+	}
+}
+
+// WithEmitCoalesce has each mapper's consecutive emits of the same key combined into a single record with a single
+// value, using combine, before they are sent to the collector. A mapper that emits a key many times in a row then
+// costs one send rather than many. It is a lighter-weight combiner that works within a single mapper: only runs of
+// the same key are combined, so the reducers may still get several values for a key, and combine must be
+// associative and such that the reducer gives the same result for a combined value as for those it replaces, as
+// adding counts is for summing them. Records emitted with weights or Meta are sent on as they are. combine must not
+// panic.
+func WithEmitCoalesce(combine func(a, b string) string) Option {
+	return func(cfg *config) {
+		cfg.emitCoalesce = combine
+	}
+}
+
+// withClock is a test-only hook that replaces the job's source of time, so that timeouts can be driven by a fake
+// clock rather than by waiting for them.
+func withClock(c clock) Option {
+	return func(cfg *config) {
+		cfg.clock = c
+	}
+}
+
+// withCollectStep is a test-only hook that has the collector call step before it waits for each result or done
+// signal. A step that releases exactly one blocked worker operation at a time makes the order in which the collector
+// sees messages deterministic, which is what's needed to reproduce ordering-sensitive bugs in a regression test.
+func withCollectStep(step func()) Option {
+	return func(cfg *config) {
+		cfg.collectStep = step
+	}
+}
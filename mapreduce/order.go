@@ -0,0 +1,60 @@
+package mapreduce
+
+import (
+	"sort"
+	"sync"
+)
+
+// emitPosition locates a record in the map output: the index of the input and of the mapper that produced it, and
+// how many records that mapper had emitted before it.
+type emitPosition struct {
+	input, mapper, emit int
+}
+
+func (p emitPosition) before(q emitPosition) bool {
+	if p.input != q.input {
+		return p.input < q.input
+	}
+	if p.mapper != q.mapper {
+		return p.mapper < q.mapper
+	}
+	return p.emit < q.emit
+}
+
+// keyOrder records the earliest position at which each key appears in the map output. Mappers report to it
+// concurrently, and in any order, so it keeps the minimum rather than the first position reported.
+type keyOrder struct {
+	sync.Mutex
+	first map[string]emitPosition
+}
+
+func newKeyOrder() *keyOrder {
+	return &keyOrder{first: make(map[string]emitPosition)}
+}
+
+// observe records that key appeared at pos.
+func (o *keyOrder) observe(key string, pos emitPosition) {
+	o.Lock()
+	defer o.Unlock()
+	if first, ok := o.first[key]; !ok || pos.before(first) {
+		o.first[key] = pos
+	}
+}
+
+// sort sorts kvs by the first position of their keys, placing keys that never appeared after the rest, by key.
+func (o *keyOrder) sort(kvs []MRInput) {
+	o.Lock()
+	defer o.Unlock()
+	sort.Slice(kvs, func(a, b int) bool {
+		posA, okA := o.first[kvs[a].Key]
+		posB, okB := o.first[kvs[b].Key]
+		switch {
+		case okA && okB:
+			return posA.before(posB)
+		case okA != okB:
+			return okA
+		default:
+			return kvs[a].Key < kvs[b].Key
+		}
+	})
+}
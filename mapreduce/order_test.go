@@ -0,0 +1,92 @@
+package mapreduce
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func keysOf(kvs []MRInput) []string {
+	keys := make([]string, 0, len(kvs))
+	for _, kv := range kvs {
+		keys = append(keys, kv.Key)
+	}
+	return keys
+}
+
+func TestMapReduceOrderedSorted(t *testing.T) {
+	input := []MRInput{{Key: "line1", Values: []string{"the quick brown fox"}}}
+
+	kvs := MapReduceOrdered(input, wordMap, countReduce)
+
+	expected := []string{"brown", "fox", "quick", "the"}
+	if !reflect.DeepEqual(expected, keysOf(kvs)) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, keysOf(kvs))
+	}
+}
+
+func TestMapReduceOrderedInsertionOrder(t *testing.T) {
+	// Each line repeats words from earlier lines and adds some new ones, in a scrambled order.
+	var input []MRInput
+	var expected []string
+	for i := 0; i < 50; i++ {
+		words := []string{fmt.Sprintf("w%d-b", i), fmt.Sprintf("w%d-a", i)}
+		if i > 0 {
+			words = append(words, fmt.Sprintf("w%d-b", i-1), fmt.Sprintf("w%d-c", i))
+		} else {
+			words = append(words, "w0-c")
+		}
+		input = append(input, MRInput{Key: fmt.Sprint(i), Values: []string{strings.Join(words, " ")}})
+		expected = append(expected, fmt.Sprintf("w%d-b", i), fmt.Sprintf("w%d-a", i), fmt.Sprintf("w%d-c", i))
+	}
+	// Reducers also emit a key of their own, which must come last.
+	reduceFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		if input.Key == "w0-a" {
+			collectChl <- MRInput{Key: "extra", Values: []string{"1"}}
+		}
+		countReduce(input, collectChl, doneChl)
+	}
+	expected = append(expected, "extra")
+
+	for run := 0; run < 10; run++ {
+		kvs := MapReduceOrdered(input, wordMap, reduceFunc, WithInsertionOrder())
+
+		if !reflect.DeepEqual(expected, keysOf(kvs)) {
+			t.Fatalf("Expected <%v>; Got <%v>", expected, keysOf(kvs))
+		}
+	}
+}
+
+func TestInsertionOrderAbandonedMapTask(t *testing.T) {
+	clock := &fakeClock{}
+	started, finished := make(chan struct{}), make(chan struct{})
+	mapFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		close(started)
+		defer close(finished)
+		// Emits long after it has been abandoned, with nothing to order its emits after the job's moving on to reduce.
+		time.Sleep(20 * time.Millisecond)
+		wordMap(input, collectChl, doneChl)
+	}
+	input := []MRInput{{Key: "stuck", Values: []string{"late"}}}
+
+	type outcome struct {
+		result map[string][]string
+		err    error
+	}
+	outcomeChl := make(chan outcome, 1)
+	go func() {
+		result, err := TryMapReduce(input, mapFunc, countReduce, WithInsertionOrder(), WithTaskTimeout(time.Minute),
+			withClock(clock))
+		outcomeChl <- outcome{result, err}
+	}()
+	<-started
+	clock.Advance(time.Minute)
+	<-finished
+	out := <-outcomeChl
+
+	if out.err == nil || len(out.result) != 0 {
+		t.Errorf("Expected a timeout and no output; Got <%v>, <%v>", out.result, out.err)
+	}
+}
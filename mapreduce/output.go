@@ -0,0 +1,39 @@
+package mapreduce
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// writePerKeyOutput writes each key of result to its own file, as configured by WithPerKeyOutput, recording any
+// failure as an error of the job.
+func (j *job) writePerKeyOutput(result map[string][]string) {
+	if err := os.MkdirAll(j.cfg.outputDir, 0o755); err != nil {
+		j.errs = append(j.errs, fmt.Errorf("mapreduce: creating output directory: %w", err))
+		return
+	}
+	nameFunc := j.cfg.outputName
+	if nameFunc == nil {
+		nameFunc = url.PathEscape
+	}
+
+	for key, values := range result {
+		name := nameFunc(key)
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+			j.errs = append(j.errs, fmt.Errorf("mapreduce: output file name %q for key %q is not a plain file name",
+				name, key))
+			continue
+		}
+		var b strings.Builder
+		for _, value := range values {
+			b.WriteString(value)
+			b.WriteByte('\n')
+		}
+		if err := os.WriteFile(filepath.Join(j.cfg.outputDir, name), []byte(b.String()), 0o644); err != nil {
+			j.errs = append(j.errs, fmt.Errorf("mapreduce: writing output for key %q: %w", key, err))
+		}
+	}
+}
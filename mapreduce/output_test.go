@@ -0,0 +1,61 @@
+package mapreduce
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// readLines returns the sorted lines of fileName.
+func readLines(t *testing.T, fileName string) []string {
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	sort.Strings(lines)
+	return lines
+}
+
+func TestWithPerKeyOutput(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	input := []MRInput{
+		{Key: "dog", Values: []string{"file1"}},
+		{Key: "dog", Values: []string{"file2"}},
+		{Key: "cat/kitten", Values: []string{"file3"}},
+	}
+
+	_, err := TryMapReduce(input, IdentityMap, IdentityReduce, WithPerKeyOutput(dir, nil))
+
+	if err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if len(files) != 2 {
+		t.Errorf("Expected a file per key; Got <%v>", files)
+	}
+	if lines := readLines(t, filepath.Join(dir, "dog")); strings.Join(lines, ",") != "file1,file2" {
+		t.Errorf("Expected <[file1 file2]>; Got <%v>", lines)
+	}
+	// The default name function escapes the '/'.
+	if lines := readLines(t, filepath.Join(dir, "cat%2Fkitten")); strings.Join(lines, ",") != "file3" {
+		t.Errorf("Expected <[file3]>; Got <%v>", lines)
+	}
+}
+
+func TestWithPerKeyOutputUnsafeName(t *testing.T) {
+	dir := t.TempDir()
+	input := []MRInput{{Key: "../escape", Values: []string{"1"}}, {Key: "ok", Values: []string{"2"}}}
+	identity := func(key string) string { return key }
+
+	_, err := TryMapReduce(input, IdentityMap, IdentityReduce, WithPerKeyOutput(dir, identity))
+
+	if err == nil || !strings.Contains(err.Error(), "../escape") {
+		t.Errorf("Expected an error naming the unsafe key; Got <%v>", err)
+	}
+	if lines := readLines(t, filepath.Join(dir, "ok")); strings.Join(lines, ",") != "2" {
+		t.Errorf("Expected <[2]>; Got <%v>", lines)
+	}
+}
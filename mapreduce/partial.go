@@ -0,0 +1,36 @@
+package mapreduce
+
+import "context"
+
+// PartialReducePolicy determines what becomes of the output of reducers that haven't finished when a job is
+// cancelled; see WithPartialReduceOnCancel.
+type PartialReducePolicy int
+
+const (
+	// Discard leaves the output of every unfinished reducer out of the result, so that each key in a cancelled
+	// job's partial result is complete. It is the default.
+	Discard PartialReducePolicy = iota
+	// Include keeps whatever unfinished reducers emitted before the job was cancelled, so that a key in its partial
+	// result may be missing some of its values.
+	Include
+)
+
+// reduceTask runs reduceFunc on input like runTask. Under the Discard policy, if the job can be cancelled, the
+// reducer's output is held back until it signals done and then sent to the collector as a single batch, which the
+// collector takes in whole or, once the job is cancelled, not at all.
+func (j *job) reduceTask(ctx context.Context, reduceFunc ReduceFunc, input MRInput, collectChl chan MRInput,
+	doneChl chan struct{}) {
+	if j.cfg.partialReduce == Include || ctx.Done() == nil {
+		runTask(reducePhase, reduceFunc, input, collectChl, doneChl)
+		return
+	}
+
+	var output []MRInput
+	intercept(reducePhase, reduceFunc, input, func(kv MRInput) {
+		output = append(output, kv)
+	})
+	if len(output) > 0 {
+		collectChl <- MRInput{batch: output}
+	}
+	doneChl <- struct{}{}
+}
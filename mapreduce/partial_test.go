@@ -0,0 +1,60 @@
+package mapreduce
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWithPartialReduceOnCancel(t *testing.T) {
+	for _, policy := range []PartialReducePolicy{Discard, Include} {
+		ctx, cancel := context.WithCancel(context.Background())
+		release := make(chan struct{})
+		// The reducer of "long" emits part of its output and then cancels the job before finishing. That "part2" has
+		// been sent means that the collector has dealt with "part1".
+		reduceFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+			if input.Key == "long" {
+				collectChl <- MRInput{Key: "long", Values: []string{"part1"}}
+				collectChl <- MRInput{Key: "long", Values: []string{"part2"}}
+				cancel()
+				<-release
+			}
+			IdentityReduce(input, collectChl, doneChl)
+		}
+		input := []MRInput{{Key: "long", Values: []string{"x"}}}
+
+		result, err := MapReduceContext(ctx, input, IdentityMap, reduceFunc, WithPartialReduceOnCancel(policy))
+		close(release)
+
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("%d: Expected <%v>; Got <%v>", policy, context.Canceled, err)
+		}
+		switch policy {
+		case Discard:
+			if len(result) != 0 {
+				t.Errorf("Discard: Expected the unfinished reducer's output to be discarded; Got <%v>", result)
+			}
+		case Include:
+			if long := result["long"]; len(long) == 0 || long[0] != "part1" {
+				t.Errorf("Include: Expected the unfinished reducer's output to start with <part1>; Got <%v>", result)
+			}
+		}
+	}
+}
+
+func TestPartialReduceDiscardKeepsFinishedReducers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	input := []MRInput{{Key: "line1", Values: []string{"the dog"}}, {Key: "line2", Values: []string{"the cat"}}}
+
+	result, err := MapReduceContext(ctx, input, wordMap, countReduce)
+
+	if err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	expected := map[string][]string{"the": {"2"}, "dog": {"1"}, "cat": {"1"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
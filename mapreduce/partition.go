@@ -0,0 +1,62 @@
+package mapreduce
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// PartitionOf returns which of n partitions, numbered from 0, key belongs to. It is the 64-bit FNV-1a hash of the
+// key's bytes modulo n, which, unlike Go's map hashing, is the same in every process and on every platform, so other
+// tools can compute the same partitioning. It panics if n is less than 1.
+func PartitionOf(key string, n int) int {
+	if n < 1 {
+		panic("mapreduce: PartitionOf needs at least one partition")
+	}
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return int(h.Sum64() % uint64(n))
+}
+
+// ReduceContext tells a PartitionReduceFunc which partition it is reducing: its number, as by PartitionOf, and all
+// of the keys in it, sorted.
+type ReduceContext struct {
+	PartitionID int
+	Keys        []string
+}
+
+// PartitionReduceFunc is the signature of the reduce function of MapReducePartitioned, which reduces a whole
+// partition at once. It receives one input per key of the partition, in the order of rc.Keys, and otherwise follows
+// the same contract as ReduceFunc, signalling done once for the partition.
+type PartitionReduceFunc func(rc ReduceContext, input []MRInput, collectChl chan MRInput, doneChl chan struct{})
+
+// MapReducePartitioned is like MapReduce, but with the map output split into partitions of keys by PartitionOf, and a
+// reducer run for each partition that has any keys rather than for each key. A reducer can then apply logic across
+// the keys of its partition, such as ranking them. It groups the map output with WithGroupKey, and so can't be
+// combined with a WithGroupKey of its own. Keys emitted without values don't reach the reducers. It panics if
+// partitions is less than 1.
+func MapReducePartitioned(input []MRInput, mapFunc MapFunc, partitions int, reduceFunc PartitionReduceFunc,
+	opts ...Option) map[string][]string {
+	if partitions < 1 {
+		panic("mapreduce: MapReducePartitioned needs at least one partition")
+	}
+	byPartition := func(kv MRInput) string {
+		return strconv.Itoa(PartitionOf(kv.Key, partitions))
+	}
+	// Regroups the partition's values by the keys they were emitted with.
+	reduce := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		groups := make(map[string][]string)
+		for i, key := range input.SourceKeys() {
+			groups[key] = append(groups[key], input.Values[i])
+		}
+		inputs := mapToKVSlice(groups)
+		sort.Slice(inputs, func(a, b int) bool { return inputs[a].Key < inputs[b].Key })
+		rc := ReduceContext{Keys: make([]string, len(inputs))}
+		rc.PartitionID, _ = strconv.Atoi(input.Key)
+		for i, kv := range inputs {
+			rc.Keys[i] = kv.Key
+		}
+		reduceFunc(rc, inputs, collectChl, doneChl)
+	}
+	return MapReduce(input, mapFunc, reduce, append(opts, WithGroupKey(byPartition))...)
+}
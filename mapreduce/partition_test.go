@@ -0,0 +1,89 @@
+package mapreduce
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// TestPartitionOfGolden pins PartitionOf's assignments, which must never change: partition files written by one
+// process are read back by others. The empty key's partition follows from FNV-1a's offset basis, 0xcbf29ce484222325.
+func TestPartitionOfGolden(t *testing.T) {
+	tests := []struct {
+		key       string
+		n         int
+		partition int
+	}{
+		{"", 1, 0},
+		{"", 2, 1},
+		{"", 16, 5},
+		{"a", 7, 5},
+		{"a", 16, 12},
+		{"dog", 2, 1},
+		{"dog", 7, 6},
+		{"dog", 16, 9},
+		{"mapreduce", 7, 0},
+		{"mapreduce", 16, 3},
+		{"word000042", 16, 11},
+		{"東京", 7, 1},
+		{"東京", 16, 15},
+	}
+	for _, test := range tests {
+		if p := PartitionOf(test.key, test.n); p != test.partition {
+			t.Errorf("PartitionOf(%q, %d): Expected <%d>; Got <%d>", test.key, test.n, test.partition, p)
+		}
+	}
+}
+
+func TestPartitionOfRange(t *testing.T) {
+	counts := make([]int, 8)
+	for _, kv := range numberedInputs(1000) {
+		counts[PartitionOf(kv.Values[0], len(counts))]++
+	}
+	for p, count := range counts {
+		if count == 0 {
+			t.Errorf("Expected every partition to get keys; Got none for partition <%d>", p)
+		}
+	}
+}
+
+func TestMapReducePartitioned(t *testing.T) {
+	input := numberedInputs(50)
+	const partitions = 4
+
+	var mu sync.Mutex
+	seen := make(map[int][]string)
+	// Records what each partition's reducer is told, and emits each key with its rank in the partition.
+	reduceFunc := func(rc ReduceContext, input []MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		mu.Lock()
+		seen[rc.PartitionID] = rc.Keys
+		mu.Unlock()
+		for i, kv := range input {
+			if kv.Key != rc.Keys[i] {
+				t.Errorf("Expected input <%d> to be <%s>; Got <%s>", i, rc.Keys[i], kv.Key)
+			}
+			collectChl <- MRInput{Key: kv.Key, Values: []string{strconv.Itoa(i)}}
+		}
+		doneChl <- struct{}{}
+	}
+
+	result := MapReducePartitioned(input, wordMap, partitions, reduceFunc)
+
+	// The expected partitions, by PartitionOf.
+	expected := make(map[int][]string)
+	for _, kv := range input {
+		word := kv.Values[0]
+		expected[PartitionOf(word, partitions)] = append(expected[PartitionOf(word, partitions)], word)
+	}
+	for _, keys := range expected {
+		sort.Strings(keys)
+	}
+	if !reflect.DeepEqual(expected, seen) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, seen)
+	}
+	if len(result) != 50 {
+		t.Errorf("Expected 50 ranked keys; Got <%d>", len(result))
+	}
+}
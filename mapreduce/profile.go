@@ -0,0 +1,105 @@
+package mapreduce
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+	"time"
+)
+
+// ProfileReport describes the resources a single map-reduce run used, as measured by Profile.
+type ProfileReport struct {
+	// Duration is how long the whole run took, and MapDuration and ReduceDuration how long each of its phases did.
+	Duration       time.Duration
+	MapDuration    time.Duration
+	ReduceDuration time.Duration
+
+	// PeakGoroutines is the most goroutines seen running at once, sampled every millisecond. It counts all of the
+	// process's goroutines, not just the job's.
+	PeakGoroutines int
+	// Mallocs and AllocBytes are the number of heap objects and bytes allocated during the run, by the whole
+	// process.
+	Mallocs    uint64
+	AllocBytes uint64
+
+	// CPUProfile and HeapProfile are the pprof CPU profile of the run and a heap profile taken at its end, for use
+	// with go tool pprof. CPUProfile is nil if CPU profiling was already in progress, e.g. under go test -cpuprofile.
+	CPUProfile  []byte
+	HeapProfile []byte
+
+	// Stats and Err are the run's statistics and error, as MapReduceWithStats and TryMapReduce would report them.
+	Stats Stats
+	Err   error
+}
+
+// Profile runs a map-reduce job like TryMapReduce while profiling it, and reports what it used. It is meant for
+// characterizing map and reduce functions on a representative input, not for production runs: the profiling slows
+// the job down, and as it measures the whole process, other work running at the same time skews the report.
+func Profile(input []MRInput, mapFunc MapFunc, reduceFunc ReduceFunc, opts ...Option) ProfileReport {
+	var report ProfileReport
+
+	var cpu bytes.Buffer
+	cpuProfiling := pprof.StartCPUProfile(&cpu) == nil
+
+	// Sample the number of goroutines until the run is over.
+	stop, sampled := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(sampled)
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			if n := runtime.NumGoroutine(); n > report.PeakGoroutines {
+				report.PeakGoroutines = n
+			}
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	var mapDone time.Time
+	var once sync.Once
+	// Time the map phase from the barrier, keeping any barrier of the caller's.
+	timeMapPhase := func(cfg *config) {
+		barrier := cfg.barrier
+		cfg.barrier = func(intermediate map[string][]string) {
+			once.Do(func() { mapDone = time.Now() })
+			if barrier != nil {
+				barrier(intermediate)
+			}
+		}
+	}
+	out := run(context.TODO(), input, []MapFunc{mapFunc}, reduceFunc, newConfig(append(opts, timeMapPhase)))
+	end := time.Now()
+	runtime.ReadMemStats(&after)
+
+	close(stop)
+	<-sampled
+	if cpuProfiling {
+		pprof.StopCPUProfile()
+		report.CPUProfile = cpu.Bytes()
+	}
+	var heap bytes.Buffer
+	if pprof.WriteHeapProfile(&heap) == nil {
+		report.HeapProfile = heap.Bytes()
+	}
+
+	report.Duration = end.Sub(start)
+	if !mapDone.IsZero() {
+		report.MapDuration = mapDone.Sub(start)
+		report.ReduceDuration = end.Sub(mapDone)
+	} else {
+		report.MapDuration = report.Duration
+	}
+	report.Mallocs = after.Mallocs - before.Mallocs
+	report.AllocBytes = after.TotalAlloc - before.TotalAlloc
+	report.Stats, report.Err = out.stats, out.err
+	return report
+}
@@ -0,0 +1,31 @@
+package mapreduce
+
+import "testing"
+
+func TestProfile(t *testing.T) {
+	report := Profile(numberedInputs(200), wordMap, countReduce)
+
+	if report.Err != nil {
+		t.Fatalf("Unexpected error <%v>", report.Err)
+	}
+	if report.Duration <= 0 || report.MapDuration <= 0 || report.ReduceDuration <= 0 {
+		t.Errorf("Expected the durations to be measured; Got <%+v>", report)
+	}
+	if report.MapDuration+report.ReduceDuration != report.Duration {
+		t.Errorf("Expected the phases to add up to the run; Got <%v> + <%v> != <%v>", report.MapDuration,
+			report.ReduceDuration, report.Duration)
+	}
+	// The job runs a goroutine per task, so there are more than the test's own at some point.
+	if report.PeakGoroutines < 2 {
+		t.Errorf("Expected a goroutine peak; Got <%d>", report.PeakGoroutines)
+	}
+	if report.Mallocs == 0 || report.AllocBytes == 0 {
+		t.Errorf("Expected allocations to be counted; Got <%d> and <%d>", report.Mallocs, report.AllocBytes)
+	}
+	if len(report.HeapProfile) == 0 {
+		t.Errorf("Expected a heap profile")
+	}
+	if report.Stats.MapTasks != 200 {
+		t.Errorf("Expected the run's stats; Got <%+v>", report.Stats)
+	}
+}
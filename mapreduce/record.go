@@ -0,0 +1,98 @@
+package mapreduce
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// WithRecorder has the job write a recording of what its collector receives to w, for debugging behavior that
+// depends on the order in which concurrent tasks happen to deliver their output. Each record a map or reduce task
+// sends, under the key it is grouped by, and each done signal is written as a line of JSON in the order received,
+// such as {"phase":"map","event":"emit","key":"the","values":["1"]} or {"phase":"map","event":"done"}. Errors and
+// warnings aren't recorded. ReplayReduce re-runs the reduce phase from a recording. If writing to w fails, the
+// recording stops there and the job fails with the error. w is written to from the collector alone, so it needn't be
+// safe for concurrent use, but a recorder shouldn't be shared by jobs that run at the same time.
+func WithRecorder(w io.Writer) Option {
+	return func(cfg *config) {
+		cfg.recorder = w
+	}
+}
+
+// recordedEvent is a line of a WithRecorder recording.
+type recordedEvent struct {
+	Phase  string   `json:"phase"`
+	Event  string   `json:"event"`
+	Key    string   `json:"key,omitempty"`
+	Values []string `json:"values,omitempty"`
+}
+
+// record writes e to the job's WithRecorder recording, if it has one.
+func (j *job) record(e recordedEvent) {
+	if j.cfg.recorder == nil || j.recordFailed {
+		return
+	}
+	if j.recordEnc == nil {
+		j.recordEnc = json.NewEncoder(j.cfg.recorder)
+	}
+	if err := j.recordEnc.Encode(e); err != nil {
+		j.recordFailed = true
+		j.errs = append(j.errs, fmt.Errorf("mapreduce: recording: %w", err))
+	}
+}
+
+// ReplayReduce re-runs the reduce phase of a job from r, a recording made with WithRecorder, with reduceFunc as its
+// reducer. The map output is grouped by key in exactly the order the recording has it, and reduceFunc is run on one
+// key at a time, in key order, so that a replay gives the same result every time, which for a reducer that depends
+// on the order of its values is the result of the recorded run. Errors that reduceFunc reports are returned along
+// with the rest of the result, as TryMapReduce does, and a line of r that can't be read is returned as a *LineError.
+func ReplayReduce(r io.Reader, reduceFunc ReduceFunc) (map[string][]string, error) {
+	grouped := make(map[string][]string)
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		text, err := br.ReadBytes('\n')
+		if len(bytes.TrimSpace(text)) > 0 {
+			var e recordedEvent
+			if err := json.Unmarshal(text, &e); err != nil {
+				return nil, &LineError{Line: n, Err: err}
+			}
+			if e.Phase == mapPhase && e.Event == "emit" {
+				grouped[e.Key] = append(grouped[e.Key], e.Values...)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, &LineError{Line: n, Err: err}
+		}
+	}
+
+	keys := make([]string, 0, len(grouped))
+	for key := range grouped {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make(map[string][]string)
+	var errs []error
+	for _, key := range keys {
+		intercept(reducePhase, reduceFunc, MRInput{Key: key, Values: grouped[key]}, func(kv MRInput) {
+			switch {
+			case kv.ctl != nil && kv.ctl.err != nil:
+				errs = append(errs, kv.ctl.err)
+			case kv.ctl == nil:
+				values, ok := result[kv.Key]
+				if !ok {
+					values = make([]string, 0, len(kv.Values))
+				}
+				result[kv.Key] = append(values, kv.Values...)
+			}
+		})
+	}
+	return result, errors.Join(errs...)
+}
@@ -0,0 +1,51 @@
+package mapreduce
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRecordThenReplay(t *testing.T) {
+	// Every value goes under the one key, so the reducer's output depends on the order the mappers' output arrived in.
+	allMap := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		collectChl <- MRInput{Key: "all", Values: input.Values}
+		doneChl <- struct{}{}
+	}
+	input := numberedInputs(50)
+	var recording bytes.Buffer
+
+	result, err := TryMapReduce(input, allMap, IdentityReduce, WithRecorder(&recording))
+	if err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	replayed, err := ReplayReduce(bytes.NewReader(recording.Bytes()), IdentityReduce)
+	if err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+
+	if !reflect.DeepEqual(result, replayed) {
+		t.Errorf("Expected <%v>; Got <%v>", result, replayed)
+	}
+	// One done signal for each mapper and for the one reducer.
+	if done := strings.Count(recording.String(), `"event":"done"`); done != len(input)+1 {
+		t.Errorf("Expected <%d> done signals; Got <%d>", len(input)+1, done)
+	}
+	again, _ := ReplayReduce(bytes.NewReader(recording.Bytes()), IdentityReduce)
+	if !reflect.DeepEqual(replayed, again) {
+		t.Errorf("Expected <%v>; Got <%v>", replayed, again)
+	}
+}
+
+func TestReplayReduceBadRecording(t *testing.T) {
+	recording := `{"phase":"map","event":"emit","key":"the","values":["1"]}` + "\nnot json\n"
+
+	_, err := ReplayReduce(strings.NewReader(recording), countReduce)
+
+	var lineErr *LineError
+	if !errors.As(err, &lineErr) || lineErr.Line != 2 {
+		t.Errorf("Expected a *LineError for line 2; Got <%v>", err)
+	}
+}
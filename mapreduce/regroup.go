@@ -0,0 +1,50 @@
+package mapreduce
+
+import "context"
+
+// RegroupReduce returns an Option that adds a second reduce stage to a job, for two-level aggregations such as
+// rolling up sums by region and city into sums by region. Once the job's reducers have all finished, their output
+// is regrouped by the key extractGroup returns for each of its keys, e.g. a prefix of a composite key (see
+// SplitKey), and each group is reduced by reduceFunc, whose output makes up the result in place of the first
+// stage's. The second stage's reducers run on the reduce executor like the first's, under the same options, such as
+// WithTaskTimeout and WithTracer, and count towards Stats.ReduceTasks. It doesn't apply to MapReduceStream or under
+// WithSink, whose output is delivered as it is reduced.
+func RegroupReduce(extractGroup func(key string) string, reduceFunc ReduceFunc) Option {
+	return func(cfg *config) {
+		cfg.regroupKey, cfg.regroupReduce = extractGroup, reduceFunc
+	}
+}
+
+// regroup runs the RegroupReduce stage on result, the output of the job's reducers, returning its output.
+func (j *job) regroup(ctx context.Context, result map[string][]string, collectChl chan MRInput) map[string][]string {
+	grouped := make(map[string][]string)
+	for key, values := range result {
+		group := j.cfg.regroupKey(key)
+		grouped[group] = append(grouped[group], values...)
+	}
+
+	doneChl := make(chan struct{}, len(grouped))
+	submitted := make(chan struct{})
+	go func() {
+		defer close(submitted)
+		for _, input := range mapToKVSlice(grouped) {
+			input := input
+			if ctx.Err() != nil {
+				doneChl <- struct{}{}
+				continue
+			}
+			j.cfg.reduceExecutor.Execute(func() {
+				if ctx.Err() != nil {
+					doneChl <- struct{}{}
+					return
+				}
+				j.wrappedReduceTask(ctx, j.cfg.regroupReduce, input, collectChl, doneChl)
+			})
+		}
+	}()
+
+	j.stats.ReduceTasks += len(grouped)
+	regrouped := j.collectResults(ctx, collectChl, len(grouped), doneChl)
+	<-submitted
+	return regrouped
+}
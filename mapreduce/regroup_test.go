@@ -0,0 +1,64 @@
+package mapreduce
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestRegroupReduce(t *testing.T) {
+	// Sales, as "region,city,amount".
+	sales := []string{"west,seattle,10", "west,portland,5", "west,seattle,7", "east,boston,3", "east,nyc,8"}
+	var input []MRInput
+	for i, sale := range sales {
+		input = append(input, MRInput{Key: string(rune('a' + i)), Values: []string{sale}})
+	}
+	// Emits each sale's amount under its region and city.
+	salesMap := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		fields := strings.Split(input.Values[0], ",")
+		collectChl <- MRInput{Key: CompositeKey(fields[0], fields[1]), Values: fields[2:]}
+		doneChl <- struct{}{}
+	}
+	region := func(key string) string { return SplitKey(key)[0] }
+
+	cities := MapReduce(input, salesMap, SumIntReduce)
+	regions, stats := MapReduceWithStats(input, salesMap, SumIntReduce, RegroupReduce(region, SumIntReduce))
+
+	expectedCities := map[string][]string{
+		CompositeKey("west", "seattle"): {"17"}, CompositeKey("west", "portland"): {"5"},
+		CompositeKey("east", "boston"): {"3"}, CompositeKey("east", "nyc"): {"8"},
+	}
+	if !reflect.DeepEqual(expectedCities, cities) {
+		t.Errorf("Expected <%v>; Got <%v>", expectedCities, cities)
+	}
+	expectedRegions := map[string][]string{"west": {"22"}, "east": {"11"}}
+	if !reflect.DeepEqual(expectedRegions, regions) {
+		t.Errorf("Expected <%v>; Got <%v>", expectedRegions, regions)
+	}
+	if stats.ReduceTasks != 6 {
+		t.Errorf("Expected 4 reduce tasks in the first stage and 2 in the second; Got <%d>", stats.ReduceTasks)
+	}
+}
+
+func TestRegroupReduceTraced(t *testing.T) {
+	input := []MRInput{{Key: "line1", Values: []string{"a1 a2 b1"}}}
+	recorder := &spanRecorder{}
+
+	MapReduce(input, wordMap, countReduce, RegroupReduce(func(key string) string { return key[:1] }, SumIntReduce),
+		WithTracer(recorder))
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	var keys []string
+	for _, span := range recorder.spans {
+		if span.name == "mapreduce.reduce" {
+			keys = append(keys, span.attrs[KeyAttribute])
+		}
+	}
+	sort.Strings(keys)
+	// The second stage's reducers, of a and b, are traced like the first's.
+	if expected := []string{"a", "a1", "a2", "b", "b1"}; !reflect.DeepEqual(expected, keys) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, keys)
+	}
+}
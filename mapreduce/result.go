@@ -0,0 +1,48 @@
+package mapreduce
+
+import (
+	"context"
+	"sort"
+)
+
+// Result is the result of a map-reduce job: each key emitted by the reducers, with its values. It is a plain map, so
+// it converts to and from map[string][]string freely.
+type Result map[string][]string
+
+// RunTyped is like MapReduce, but returns the result as a Result.
+func RunTyped(input []MRInput, mapFunc MapFunc, reduceFunc ReduceFunc, opts ...Option) Result {
+	out := run(context.TODO(), input, []MapFunc{mapFunc}, reduceFunc, newConfig(opts))
+	out.mustSucceed()
+	return Result(out.result)
+}
+
+// Get returns the values of key, or nil if the result doesn't have it.
+func (r Result) Get(key string) []string {
+	return r[key]
+}
+
+// Keys returns the result's keys, sorted if sorted is true and otherwise in no particular order.
+func (r Result) Keys(sorted bool) []string {
+	keys := make([]string, 0, len(r))
+	for key := range r {
+		keys = append(keys, key)
+	}
+	if sorted {
+		sort.Strings(keys)
+	}
+	return keys
+}
+
+// Len returns the number of keys in the result.
+func (r Result) Len() int {
+	return len(r)
+}
+
+// Range calls fn with each key of the result and its values, in sorted key order, until fn returns false.
+func (r Result) Range(fn func(key string, values []string) bool) {
+	for _, key := range r.Keys(true) {
+		if !fn(key, r[key]) {
+			return
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package mapreduce
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func wordCountResult() Result {
+	input := []MRInput{{Key: "line1", Values: []string{"the dog and the cat"}}}
+	return RunTyped(input, wordMap, countReduce)
+}
+
+func TestResultGet(t *testing.T) {
+	r := wordCountResult()
+
+	if expected := []string{"2"}; !reflect.DeepEqual(expected, r.Get("the")) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, r.Get("the"))
+	}
+	if r.Get("bird") != nil {
+		t.Errorf("Expected no values for a missing key; Got <%v>", r.Get("bird"))
+	}
+}
+
+func TestResultKeys(t *testing.T) {
+	r := wordCountResult()
+
+	expected := []string{"and", "cat", "dog", "the"}
+	if !reflect.DeepEqual(expected, r.Keys(true)) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, r.Keys(true))
+	}
+	unsorted := r.Keys(false)
+	sort.Strings(unsorted)
+	if !reflect.DeepEqual(expected, unsorted) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, unsorted)
+	}
+}
+
+func TestResultLen(t *testing.T) {
+	if n := wordCountResult().Len(); n != 4 {
+		t.Errorf("Expected <4>; Got <%d>", n)
+	}
+	if n := (Result{}).Len(); n != 0 {
+		t.Errorf("Expected <0>; Got <%d>", n)
+	}
+}
+
+func TestResultRange(t *testing.T) {
+	r := wordCountResult()
+
+	var keys []string
+	r.Range(func(key string, values []string) bool {
+		keys = append(keys, key)
+		return key != "cat"
+	})
+
+	if expected := []string{"and", "cat"}; !reflect.DeepEqual(expected, keys) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, keys)
+	}
+}
+
+func TestResultConversion(t *testing.T) {
+	raw := map[string][]string(wordCountResult())
+
+	expected := map[string][]string{"the": {"2"}, "dog": {"1"}, "and": {"1"}, "cat": {"1"}}
+	if !reflect.DeepEqual(expected, raw) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, raw)
+	}
+}
@@ -0,0 +1,74 @@
+package mapreduce
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// WithMapRetries has the job run a map function that fails on an input, by panicking or via a built-in error, up to
+// n more times on it. Only the output of the last attempt is kept, so an input that succeeds on a retry contributes
+// exactly what one successful run would have. If every attempt fails, the last attempt's errors are reported, or
+// under WithSkipFailedInputs, the input is skipped. Retries are immediate unless WithRetryBackoff is set. As under
+// WithSkipFailedInputs, each attempt's output is held until the map function finishes.
+func WithMapRetries(n int) Option {
+	return func(cfg *config) {
+		cfg.mapRetries = n
+	}
+}
+
+// WithRetryBackoff has the retries of WithMapRetries wait before each attempt, so that a flaky dependency isn't
+// hammered: initial before the first retry, then factor times longer before each one after that, but never longer
+// than max. A max of 0 means no cap, though no delay is longer than the longest time.Duration. A factor below 1 is
+// taken to be 1. Retries of an input wait on the goroutine of its map task, and are given up once the job is
+// cancelled.
+func WithRetryBackoff(initial time.Duration, factor float64, max time.Duration) Option {
+	return func(cfg *config) {
+		cfg.retryBackoff = retryBackoff{initial: initial, factor: factor, max: max}
+	}
+}
+
+// retryBackoff is the schedule of WithRetryBackoff.
+type retryBackoff struct {
+	initial time.Duration
+	factor  float64
+	max     time.Duration
+}
+
+// delay returns how long to wait before the retry-th retry, counting from 0.
+func (b retryBackoff) delay(retry int) time.Duration {
+	d := float64(b.initial) * math.Pow(math.Max(b.factor, 1), float64(retry))
+	if b.max > 0 && d > float64(b.max) {
+		return b.max
+	}
+	// Past this, converting d would overflow.
+	if d >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(d)
+}
+
+// attemptMap runs mapFunc on input, holding back its output, and as long as it fails and WithMapRetries allows,
+// runs it again after the delay of WithRetryBackoff, unless ctx is cancelled while it waits. It returns the output
+// of the last attempt, and whether that attempt failed.
+func (j *job) attemptMap(ctx context.Context, mapFunc MapFunc, input MRInput) (output []MRInput, failed bool) {
+	for retry := 0; ; retry++ {
+		output, failed = nil, false
+		intercept(mapPhase, mapFunc, input, func(kv MRInput) {
+			if kv.ctl != nil && kv.ctl.err != nil {
+				failed = true
+			}
+			output = append(output, kv)
+		})
+		if !failed || retry >= j.cfg.mapRetries {
+			return output, failed
+		}
+		if d := j.cfg.retryBackoff.delay(retry); d > 0 {
+			select {
+			case <-j.cfg.clock.After(d):
+			case <-ctx.Done():
+				return output, failed
+			}
+		}
+	}
+}
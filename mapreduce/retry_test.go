@@ -0,0 +1,120 @@
+package mapreduce
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithRetryBackoff(t *testing.T) {
+	clock := &fakeClock{}
+	var attempts int32
+	mapFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		if atomic.AddInt32(&attempts, 1) <= 3 {
+			panic("flaky dependency")
+		}
+		wordMap(input, collectChl, doneChl)
+	}
+	input := []MRInput{{Key: "line1", Values: []string{"the dog"}}}
+
+	resultChl := make(chan outcome, 1)
+	go func() {
+		result, err := TryMapReduce(input, mapFunc, countReduce, WithMapRetries(5),
+			WithRetryBackoff(10*time.Millisecond, 2, 25*time.Millisecond), withClock(clock))
+		resultChl <- outcome{result: result, err: err}
+	}()
+
+	// Each retry waits on the clock in turn: 10ms, then twice that, then the 25ms cap.
+	var waits []time.Duration
+	for i := 0; i < 3; i++ {
+		clock.BlockUntil(1)
+		clock.mu.Lock()
+		wait := clock.waiters[0].at.Sub(clock.now)
+		clock.mu.Unlock()
+		waits = append(waits, wait)
+		clock.Advance(wait)
+	}
+	out := <-resultChl
+
+	expectedWaits := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 25 * time.Millisecond}
+	if !reflect.DeepEqual(expectedWaits, waits) {
+		t.Errorf("Expected <%v>; Got <%v>", expectedWaits, waits)
+	}
+	if out.err != nil {
+		t.Fatalf("Unexpected error <%v>", out.err)
+	}
+	expected := map[string][]string{"the": {"1"}, "dog": {"1"}}
+	if !reflect.DeepEqual(expected, out.result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, out.result)
+	}
+	if attempts != 4 {
+		t.Errorf("Expected <4> attempts; Got <%d>", attempts)
+	}
+}
+
+func TestWithMapRetriesExhausted(t *testing.T) {
+	var attempts int32
+	mapFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		atomic.AddInt32(&attempts, 1)
+		collectChl <- MRInput{Key: "partial", Values: []string{"1"}}
+		panic("always fails")
+	}
+	input := []MRInput{{Key: "line1", Values: []string{"the dog"}}}
+
+	result, err := TryMapReduce(input, mapFunc, countReduce, WithMapRetries(2))
+
+	if err == nil {
+		t.Errorf("Expected an error once the retries ran out; Got <nil>")
+	}
+	// Only the last attempt's output is kept.
+	expected := map[string][]string{"partial": {"1"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected <3> attempts; Got <%d>", attempts)
+	}
+}
+
+func TestWithRetryBackoffCancelled(t *testing.T) {
+	clock := &fakeClock{}
+	mapFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		panic("always fails")
+	}
+	input := []MRInput{{Key: "line1", Values: []string{"the dog"}}}
+	ctx, cancel := context.WithCancel(context.Background())
+	before := runtime.NumGoroutine()
+
+	resultChl := make(chan outcome, 1)
+	go func() {
+		result, err := MapReduceContext(ctx, input, mapFunc, countReduce, WithMapRetries(5),
+			WithRetryBackoff(time.Hour, 2, 0), withClock(clock))
+		resultChl <- outcome{result: result, err: err}
+	}()
+
+	// The clock is never advanced, so the map task only finishes if the retry stops waiting once it's cancelled.
+	clock.BlockUntil(1)
+	cancel()
+	out := <-resultChl
+
+	if !errors.Is(out.err, context.Canceled) {
+		t.Errorf("Expected <%v>; Got <%v>", context.Canceled, out.err)
+	}
+	waitForGoroutines(t, before)
+}
+
+func TestRetryBackoffDelayUncapped(t *testing.T) {
+	b := retryBackoff{initial: time.Second, factor: 2}
+	if d := b.delay(10); d != 1024*time.Second {
+		t.Errorf("Expected <%v>; Got <%v>", 1024*time.Second, d)
+	}
+	// Doubling a second a thousand times is far past what a time.Duration holds.
+	if d := b.delay(1000); d != math.MaxInt64 {
+		t.Errorf("Expected <%v>; Got <%v>", time.Duration(math.MaxInt64), d)
+	}
+}
@@ -0,0 +1,23 @@
+package mapreduce
+
+// WithReducerRouter has the job choose the reduce function of each key of its map output with route, so that one job
+// can aggregate different families of keys differently, e.g. by a prefix such as "sum:" or "max:". Keys that route
+// returns nil for are reduced by the job's own reduce function. route is called once for each key, as its reduce
+// task starts, so it must be safe to call from several goroutines at once. Options that wrap the reduce step, such as
+// WithReduceTree, apply to whichever reducer is chosen.
+func WithReducerRouter(route func(key string) ReduceFunc) Option {
+	return func(cfg *config) {
+		cfg.reducerRouter = route
+	}
+}
+
+// routeReduce returns a reducer that reduces each key with the reducer route chooses for it, or defaultReduce.
+func routeReduce(route func(key string) ReduceFunc, defaultReduce ReduceFunc) ReduceFunc {
+	return func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		if reduceFunc := route(input.Key); reduceFunc != nil {
+			reduceFunc(input, collectChl, doneChl)
+			return
+		}
+		defaultReduce(input, collectChl, doneChl)
+	}
+}
@@ -0,0 +1,50 @@
+package mapreduce
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWithReducerRouter(t *testing.T) {
+	input := []MRInput{
+		{Key: "1", Values: []string{"sum:bytes 10", "max:latency 7", "count:requests x"}},
+		{Key: "2", Values: []string{"sum:bytes 5", "max:latency 12", "count:requests y"}},
+		{Key: "3", Values: []string{"sum:bytes 1", "max:latency 3"}},
+	}
+	mapFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		for _, value := range input.Values {
+			fields := strings.Fields(value)
+			collectChl <- MRInput{Key: fields[0], Values: []string{fields[1]}}
+		}
+		doneChl <- struct{}{}
+	}
+	maxReduce := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		max := 0
+		for _, value := range input.Values {
+			if n, _ := strconv.Atoi(value); n > max {
+				max = n
+			}
+		}
+		EmitInt(collectChl, input.Key, max)
+		doneChl <- struct{}{}
+	}
+	route := func(key string) ReduceFunc {
+		switch {
+		case strings.HasPrefix(key, "sum:"):
+			return SumIntReduce
+		case strings.HasPrefix(key, "max:"):
+			return maxReduce
+		}
+		return nil
+	}
+
+	result := MapReduce(input, mapFunc, CountReduce, WithReducerRouter(route))
+
+	// "count:" keys aren't routed, so they fall back to the job's reducer.
+	expected := map[string][]string{"sum:bytes": {"16"}, "max:latency": {"12"}, "count:requests": {"2"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
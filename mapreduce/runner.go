@@ -0,0 +1,67 @@
+package mapreduce
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrRunnerClosed is returned by Runner.Run once the Runner has been closed.
+var ErrRunnerClosed = errors.New("mapreduce: runner is closed")
+
+// Runner runs map-reduce jobs on Pools of its own, which it keeps between jobs. Close releases them.
+type Runner struct {
+	opts       []Option
+	mapPool    *Pool
+	reducePool *Pool
+
+	mu     sync.Mutex
+	closed bool
+	// running counts the jobs in flight, which Close waits for.
+	running sync.WaitGroup
+}
+
+// NewRunner returns a Runner that runs map tasks on a Pool of mapWorkers goroutines and reduce tasks on one of
+// reduceWorkers goroutines, each at least 1 as with NewPool. opts apply to every job it runs.
+func NewRunner(mapWorkers, reduceWorkers int, opts ...Option) *Runner {
+	return &Runner{opts: opts, mapPool: NewPool(mapWorkers), reducePool: NewPool(reduceWorkers)}
+}
+
+// Run runs a job like MapReduceContext on the Runner's Pools, with the Runner's options followed by opts. It may be
+// called from any number of goroutines at once. It returns ErrRunnerClosed if the Runner has been closed.
+func (r *Runner) Run(ctx context.Context, input []MRInput, mapFunc MapFunc, reduceFunc ReduceFunc,
+	opts ...Option) (map[string][]string, error) {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil, ErrRunnerClosed
+	}
+	r.running.Add(1)
+	r.mu.Unlock()
+	defer r.running.Done()
+
+	return MapReduceContext(ctx, input, mapFunc, reduceFunc, append(r.options(), opts...)...)
+}
+
+// options returns the options of every job the Runner runs: its Pools, followed by the options it was created
+// with.
+func (r *Runner) options() []Option {
+	return append([]Option{WithMapExecutor(r.mapPool), WithReduceExecutor(r.reducePool)}, r.opts...)
+}
+
+// Close waits for the jobs in flight to complete, and then stops the Runner's Pools and waits for their goroutines to
+// exit. Run fails from the moment Close is called. Closing a Runner again has no effect.
+func (r *Runner) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	r.mu.Unlock()
+
+	r.running.Wait()
+	r.mapPool.Close()
+	r.reducePool.Close()
+	return nil
+}
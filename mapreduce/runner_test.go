@@ -0,0 +1,35 @@
+package mapreduce
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+)
+
+func TestRunnerClose(t *testing.T) {
+	before := runtime.NumGoroutine()
+	runner := NewRunner(4, 3)
+
+	result, err := runner.Run(context.Background(), numberedInputs(20), wordMap, countReduce)
+	if err != nil {
+		t.Fatalf("Expected no error; Got <%v>", err)
+	}
+	if len(result) != 20 {
+		t.Errorf("Expected 20 results; Got <%d>", len(result))
+	}
+
+	if err := runner.Close(); err != nil {
+		t.Errorf("Expected no error; Got <%v>", err)
+	}
+	// The Pools' goroutines have returned once Close does, but may take a moment to be gone.
+	waitForGoroutines(t, before)
+
+	_, err = runner.Run(context.Background(), numberedInputs(1), wordMap, countReduce)
+	if !errors.Is(err, ErrRunnerClosed) {
+		t.Errorf("Expected <%v>; Got <%v>", ErrRunnerClosed, err)
+	}
+	if err := runner.Close(); err != nil {
+		t.Errorf("Expected no error; Got <%v>", err)
+	}
+}
@@ -0,0 +1,34 @@
+package mapreduce
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+)
+
+// WithInputSampleRate has master map a reproducible sample of about rate of the job's inputs, e.g. 0.01 to try a
+// pipeline on around 1% of its data, dropping the rest as WithInputFilter would. Whether an input is sampled depends
+// only on a hash of its key and seed, so the same seed picks the same inputs on every run, and in every process,
+// whatever else is in the input, while another seed picks a different sample. With a rate of 1 or more every input is
+// kept, and with one of 0 or less none is. Inputs batched by BatchInputs are sampled one by one. It applies after any
+// WithInputFilter.
+func WithInputSampleRate(rate float64, seed int64) Option {
+	return func(cfg *config) {
+		cfg.sample = &inputSample{rate: rate, seed: seed}
+	}
+}
+
+// inputSample is the sampling of WithInputSampleRate.
+type inputSample struct {
+	rate float64
+	seed int64
+}
+
+// keep reports whether input is in the sample, by mapping the hash of the seed and its key onto [0, 1).
+func (s *inputSample) keep(input MRInput) bool {
+	h := fnv.New64a()
+	var seed [8]byte
+	binary.LittleEndian.PutUint64(seed[:], uint64(s.seed))
+	h.Write(seed[:])
+	h.Write([]byte(input.Key))
+	return float64(mix64(h.Sum64())>>11)/(1<<53) < s.rate
+}
@@ -0,0 +1,44 @@
+package mapreduce
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithInputSampleRate(t *testing.T) {
+	input := numberedInputs(2000)
+	sampledKeys := func(rate float64, seed int64, input []MRInput) map[string][]string {
+		return MapReduce(input, IdentityMap, IdentityReduce, WithInputSampleRate(rate, seed))
+	}
+
+	first := sampledKeys(0.1, 42, input)
+	second := sampledKeys(0.1, 42, input)
+	other := sampledKeys(0.1, 7, input)
+
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Expected the same sample for the same seed; Got <%v> and <%v>", first, second)
+	}
+	if reflect.DeepEqual(first, other) {
+		t.Errorf("Expected a different sample for a different seed; Got <%v>", other)
+	}
+	if len(first) < 150 || len(first) > 250 {
+		t.Errorf("Expected about <200> inputs sampled; Got <%d>", len(first))
+	}
+	// An input's selection doesn't depend on the rest of the input.
+	reversed := make([]MRInput, 0, len(input))
+	for i := len(input) - 1; i >= 500; i-- {
+		reversed = append(reversed, input[i])
+	}
+	for key := range sampledKeys(0.1, 42, reversed) {
+		if _, ok := first[key]; !ok {
+			t.Errorf("Expected <%v> to be sampled from the whole input too", key)
+		}
+	}
+
+	if all := sampledKeys(1, 42, input); len(all) != len(input) {
+		t.Errorf("Expected every input at a rate of 1; Got <%d>", len(all))
+	}
+	if none := sampledKeys(0, 42, input); len(none) != 0 {
+		t.Errorf("Expected no inputs at a rate of 0; Got <%d>", len(none))
+	}
+}
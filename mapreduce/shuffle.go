@@ -0,0 +1,222 @@
+package mapreduce
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Shuffler groups a job's map output by key somewhere other than in memory, so that the reduce phase can work through
+// data too large to hold at once; see WithShuffler. The collector calls Add with each map output record, from a
+// single goroutine, and then Groups once the map phase is over.
+type Shuffler interface {
+	// Add stores kv, whose key is the one it is grouped under.
+	Add(kv MRInput) error
+	// Groups calls fn with each key that was added and all of the values added under it, one key at a time. If fn
+	// returns an error, Groups stops and returns it.
+	Groups(fn func(group MRInput) error) error
+}
+
+// DiskShuffler is a Shuffler that sorts the map output on disk: it buffers records in memory, writing each buffer
+// full out as a sorted run file, and merges the runs to produce the groups. Memory use is therefore bounded by the
+// size of a run plus that of the largest group. Groups come sorted by their quoted keys, and a key's values sorted
+// too rather than in the order they were emitted in. A DiskShuffler is for a single job.
+type DiskShuffler struct {
+	dir     string
+	runSize int
+	buf     []string
+	runs    []string
+}
+
+// NewDiskShuffler returns a DiskShuffler that writes its runs, of runSize records each, to a new directory within
+// dir, or within the system's temporary directory if dir is empty. Close removes it.
+func NewDiskShuffler(dir string, runSize int) (*DiskShuffler, error) {
+	dir, err := os.MkdirTemp(dir, "mapreduce-shuffle-")
+	if err != nil {
+		return nil, fmt.Errorf("mapreduce: creating shuffle directory: %w", err)
+	}
+	if runSize < 1 {
+		runSize = 1
+	}
+	return &DiskShuffler{dir: dir, runSize: runSize}, nil
+}
+
+// Add implements Shuffler. Each of kv's values is stored as a line of its own, and a key without values as a line
+// with just the key, so that it is still grouped.
+func (s *DiskShuffler) Add(kv MRInput) error {
+	key := strconv.Quote(kv.Key)
+	if len(kv.Values) == 0 {
+		s.buf = append(s.buf, key)
+	}
+	for _, value := range kv.Values {
+		// Quoted strings hold no raw tabs, so the tab unambiguously ends the key.
+		s.buf = append(s.buf, key+"\t"+strconv.Quote(value))
+	}
+	if len(s.buf) >= s.runSize {
+		return s.flush()
+	}
+	return nil
+}
+
+// flush writes the buffered records out as a sorted run.
+func (s *DiskShuffler) flush() error {
+	if len(s.buf) == 0 {
+		return nil
+	}
+	sort.Strings(s.buf)
+	name := filepath.Join(s.dir, fmt.Sprintf("run%06d", len(s.runs)))
+	content := strings.Join(s.buf, "\n") + "\n"
+	if err := os.WriteFile(name, []byte(content), 0o600); err != nil {
+		return fmt.Errorf("mapreduce: writing shuffle run: %w", err)
+	}
+	s.runs = append(s.runs, name)
+	s.buf = s.buf[:0]
+	return nil
+}
+
+// Groups implements Shuffler by merging the sorted runs. Lines sort by their quoted key first, and as no quoted key
+// is a prefix of another followed by a tab, all of a key's lines are adjacent in the merge.
+func (s *DiskShuffler) Groups(fn func(group MRInput) error) error {
+	if err := s.flush(); err != nil {
+		return err
+	}
+	var runs runHeap
+	for _, name := range s.runs {
+		f, err := os.Open(name)
+		if err != nil {
+			return fmt.Errorf("mapreduce: reading shuffle run: %w", err)
+		}
+		defer f.Close()
+		r := &shuffleRun{scanner: bufio.NewScanner(f)}
+		r.scanner.Buffer(nil, 1<<30)
+		if r.next() {
+			runs = append(runs, r)
+		} else if r.scanner.Err() != nil {
+			return fmt.Errorf("mapreduce: reading shuffle run: %w", r.scanner.Err())
+		}
+	}
+	heap.Init(&runs)
+
+	var group *MRInput
+	for runs.Len() > 0 {
+		r := runs[0]
+		key, value, hasValue, err := parseShuffleLine(r.line)
+		if err != nil {
+			return err
+		}
+		if group != nil && group.Key != key {
+			if err := fn(*group); err != nil {
+				return err
+			}
+			group = nil
+		}
+		if group == nil {
+			group = &MRInput{Key: key, Values: []string{}}
+		}
+		if hasValue {
+			group.Values = append(group.Values, value)
+		}
+
+		if r.next() {
+			heap.Fix(&runs, 0)
+		} else {
+			if r.scanner.Err() != nil {
+				return fmt.Errorf("mapreduce: reading shuffle run: %w", r.scanner.Err())
+			}
+			heap.Pop(&runs)
+		}
+	}
+	if group != nil {
+		return fn(*group)
+	}
+	return nil
+}
+
+// Close removes the DiskShuffler's run files.
+func (s *DiskShuffler) Close() error {
+	return os.RemoveAll(s.dir)
+}
+
+// parseShuffleLine decodes a line written by DiskShuffler.Add.
+func parseShuffleLine(line string) (key, value string, hasValue bool, err error) {
+	quotedKey, err := strconv.QuotedPrefix(line)
+	if err == nil {
+		key, err = strconv.Unquote(quotedKey)
+	}
+	if err == nil && len(line) > len(quotedKey) {
+		hasValue = true
+		value, err = strconv.Unquote(line[len(quotedKey)+1:])
+	}
+	if err != nil {
+		return "", "", false, fmt.Errorf("mapreduce: corrupt shuffle run line %q: %w", line, err)
+	}
+	return key, value, hasValue, nil
+}
+
+// shuffleRun is a sorted run being merged, positioned at its current line.
+type shuffleRun struct {
+	scanner *bufio.Scanner
+	line    string
+}
+
+func (r *shuffleRun) next() bool {
+	if !r.scanner.Scan() {
+		return false
+	}
+	r.line = r.scanner.Text()
+	return true
+}
+
+// runHeap orders runs by their current lines, for merging.
+type runHeap []*shuffleRun
+
+func (h runHeap) Len() int            { return len(h) }
+func (h runHeap) Less(a, b int) bool  { return h[a].line < h[b].line }
+func (h runHeap) Swap(a, b int)       { h[a], h[b] = h[b], h[a] }
+func (h *runHeap) Push(x interface{}) { *h = append(*h, x.(*shuffleRun)) }
+func (h *runHeap) Pop() interface{} {
+	old := *h
+	r := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return r
+}
+
+// reduceShuffled runs reduce on each group of the job's Shuffler, as a single task from the collector's point of
+// view: it signals doneChl once, after all of the reducers it started have finished. It returns the number of
+// groups.
+func (j *job) reduceShuffled(ctx context.Context, reduce func(MRInput, chan struct{}), collectChl chan MRInput,
+	doneChl chan struct{}) int {
+	var wg sync.WaitGroup
+	localDoneChl := make(chan struct{})
+	go func() {
+		for range localDoneChl {
+			wg.Done()
+		}
+	}()
+
+	groups := 0
+	err := j.shuffler().Groups(func(group MRInput) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		groups++
+		wg.Add(1)
+		reduce(group, localDoneChl)
+		return nil
+	})
+	wg.Wait()
+	close(localDoneChl)
+
+	if err != nil && ctx.Err() == nil {
+		emitError(collectChl, err)
+	}
+	doneChl <- struct{}{}
+	return groups
+}
@@ -0,0 +1,95 @@
+package mapreduce
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestWithShuffler(t *testing.T) {
+	input := append(numberedInputs(20), numberedInputs(20)...)
+	input = append(input, MRInput{Key: "tricky", Values: []string{"a\tb \"quoted\"\nword", "", "word000003"}})
+
+	expected := MapReduce(input, wordMap, countReduce)
+
+	dir := t.TempDir()
+	// A small run size makes the shuffler merge several runs.
+	shuffler, err := NewDiskShuffler(dir, 7)
+	if err != nil {
+		t.Fatalf("Expected no error; Got <%v>", err)
+	}
+	defer shuffler.Close()
+	pool := NewPool(2)
+	defer pool.Close()
+
+	result, stats := MapReduceWithStats(input, wordMap, countReduce, WithShuffler(shuffler), WithReduceExecutor(pool))
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+	if stats.ReduceTasks != len(expected) {
+		t.Errorf("Expected %d reduce tasks; Got <%d>", len(expected), stats.ReduceTasks)
+	}
+	if len(shuffler.runs) < 2 {
+		t.Errorf("Expected the shuffler to write several runs; Got <%d>", len(shuffler.runs))
+	}
+
+	if err := shuffler.Close(); err != nil {
+		t.Errorf("Expected no error; Got <%v>", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("Expected Close to remove the runs; Got <%v>", entries)
+	}
+}
+
+func TestWithShufflerInto(t *testing.T) {
+	shuffler, err := NewDiskShuffler(t.TempDir(), 2)
+	if err != nil {
+		t.Fatalf("Expected no error; Got <%v>", err)
+	}
+	defer shuffler.Close()
+	existing := map[string][]string{"old": {"2"}, "k": {"3"}}
+	input := []MRInput{{Key: "line1", Values: []string{"k"}}}
+
+	result := MapReduceInto(existing, input, wordMap, SumIntReduce, WithShuffler(shuffler))
+
+	expected := map[string][]string{"old": {"2"}, "k": {"4"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
+
+func TestDiskShufflerGroups(t *testing.T) {
+	shuffler, err := NewDiskShuffler(t.TempDir(), 2)
+	if err != nil {
+		t.Fatalf("Expected no error; Got <%v>", err)
+	}
+	defer shuffler.Close()
+	for _, kv := range []MRInput{
+		{Key: "b", Values: []string{"2", "1"}},
+		{Key: "a", Values: []string{"x"}},
+		{Key: "a\t", Values: []string{"y"}},
+		{Key: "c"},
+		{Key: "b", Values: []string{"3"}},
+	} {
+		if err := shuffler.Add(kv); err != nil {
+			t.Fatalf("Expected no error; Got <%v>", err)
+		}
+	}
+
+	var groups []MRInput
+	shuffler.Groups(func(group MRInput) error {
+		groups = append(groups, group)
+		return nil
+	})
+
+	expected := []MRInput{
+		{Key: "a", Values: []string{"x"}},
+		{Key: "a\t", Values: []string{"y"}},
+		{Key: "b", Values: []string{"1", "2", "3"}},
+		{Key: "c", Values: []string{}},
+	}
+	if !reflect.DeepEqual(expected, groups) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, groups)
+	}
+}
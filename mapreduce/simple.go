@@ -0,0 +1,38 @@
+package mapreduce
+
+import "strconv"
+
+// MapReduceStrings is the simplest way to run a job: over lines of text, with map and reduce functions that return
+// their output rather than dealing with channels. mapFunc is called with each line and returns the records to emit;
+// reduceFunc is called with each key and all of its values and returns the key's values in the result, or nothing to
+// leave the key out. Like MapReduce, it panics if either function does.
+func MapReduceStrings(lines []string, mapFunc func(line string) []MRInput,
+	reduceFunc func(key string, values []string) []string) map[string][]string {
+	input := make([]MRInput, len(lines))
+	for i, line := range lines {
+		input[i] = MRInput{Key: strconv.Itoa(i + 1), Values: []string{line}}
+	}
+	return MapReduce(input, simpleMap(mapFunc), simpleReduce(reduceFunc))
+}
+
+// simpleMap adapts a map function over a single line to a MapFunc, calling it with each of its input's values.
+func simpleMap(fn func(line string) []MRInput) MapFunc {
+	return func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		for _, value := range input.Values {
+			for _, kv := range fn(value) {
+				collectChl <- kv
+			}
+		}
+		doneChl <- struct{}{}
+	}
+}
+
+// simpleReduce adapts a reduce function returning a key's values to a ReduceFunc.
+func simpleReduce(fn func(key string, values []string) []string) ReduceFunc {
+	return func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		if values := fn(input.Key, input.Values); len(values) > 0 {
+			collectChl <- MRInput{Key: input.Key, Values: values}
+		}
+		doneChl <- struct{}{}
+	}
+}
@@ -0,0 +1,38 @@
+package mapreduce
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func ExampleMapReduceStrings() {
+	lines := []string{"the quick brown fox", "jumps over the lazy dog", "the end"}
+
+	counts := MapReduceStrings(lines,
+		func(line string) []MRInput {
+			var words []MRInput
+			for _, word := range strings.Fields(line) {
+				words = append(words, MRInput{Key: word, Values: []string{"1"}})
+			}
+			return words
+		},
+		func(word string, ones []string) []string {
+			return []string{strconv.Itoa(len(ones))}
+		})
+
+	Result(counts).Range(func(word string, count []string) bool {
+		fmt.Println(word, count[0])
+		return true
+	})
+	// Output:
+	// brown 1
+	// dog 1
+	// end 1
+	// fox 1
+	// jumps 1
+	// lazy 1
+	// over 1
+	// quick 1
+	// the 3
+}
@@ -0,0 +1,33 @@
+package mapreduce
+
+// Sink is where a job started with WithSink delivers its reduce output: a map, a writer, a callback or a database,
+// for example. Collect is called with each record that a reducer emits, with its key normalized as it would be in
+// the result, and Finish once the job is over, before it returns, even if it failed, was cancelled before its reduce
+// phase or was a dry run. Both are called from the job's collector goroutine, one call at a time, so a Sink needn't
+// be safe for concurrent use; but the job waits for them, so a slow Sink slows it down.
+type Sink interface {
+	Collect(kv MRInput)
+	Finish()
+}
+
+// MapSink is a Sink that gathers the records into a map, as a job does without one.
+type MapSink struct {
+	Result map[string][]string
+}
+
+// NewMapSink returns an empty MapSink.
+func NewMapSink() *MapSink {
+	return &MapSink{Result: make(map[string][]string)}
+}
+
+// Collect adds kv's values to those of its key. A key sent without values is recorded all the same.
+func (s *MapSink) Collect(kv MRInput) {
+	values, ok := s.Result[kv.Key]
+	if !ok {
+		values = make([]string, 0, len(kv.Values))
+	}
+	s.Result[kv.Key] = append(values, kv.Values...)
+}
+
+// Finish implements Sink; a MapSink has nothing to do.
+func (s *MapSink) Finish() {}
@@ -0,0 +1,73 @@
+package mapreduce
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// countingSink counts what it is sent.
+type countingSink struct {
+	records, values, finishes int
+	keys                      map[string]bool
+}
+
+func (s *countingSink) Collect(kv MRInput) {
+	if s.keys == nil {
+		s.keys = make(map[string]bool)
+	}
+	s.records++
+	s.values += len(kv.Values)
+	s.keys[kv.Key] = true
+}
+
+func (s *countingSink) Finish() {
+	s.finishes++
+}
+
+func TestWithSink(t *testing.T) {
+	sink := &countingSink{}
+
+	result := MapReduce(numberedInputs(50), wordMap, countReduce, WithSink(sink))
+
+	if len(result) != 0 {
+		t.Errorf("Expected the output to go to the sink instead of the result; Got <%v>", result)
+	}
+	if sink.records != 50 || sink.values != 50 || len(sink.keys) != 50 {
+		t.Errorf("Expected 50 records, values and keys; Got <%d>, <%d> and <%d>", sink.records, sink.values,
+			len(sink.keys))
+	}
+	if sink.finishes != 1 {
+		t.Errorf("Expected Finish to be called once; Got <%d>", sink.finishes)
+	}
+}
+
+func TestWithSinkCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	// The job is cancelled by its first mapper, so it ends in the map phase.
+	mapFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		cancel()
+		wordMap(input, collectChl, doneChl)
+	}
+
+	for _, opts := range [][]Option{nil, {WithDryRun()}} {
+		sink := &countingSink{}
+
+		MapReduceContext(ctx, numberedInputs(5), mapFunc, countReduce, append(opts, WithSink(sink))...)
+
+		if sink.finishes != 1 {
+			t.Errorf("Expected Finish to be called once; Got <%d>", sink.finishes)
+		}
+	}
+}
+
+func TestMapSink(t *testing.T) {
+	sink := NewMapSink()
+
+	MapReduce(numberedInputs(50), wordMap, countReduce, WithSink(sink))
+
+	expected := MapReduce(numberedInputs(50), wordMap, countReduce)
+	if !reflect.DeepEqual(expected, sink.Result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, sink.Result)
+	}
+}
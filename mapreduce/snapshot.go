@@ -0,0 +1,175 @@
+package mapreduce
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ConfigSnapshot records how a job is configured, in a form that can be logged or stored with its results, e.g. as
+// JSON. Options whose settings are functions or other values that can't be serialized, such as WithFinalizer, are
+// only recorded by name in Hooks.
+type ConfigSnapshot struct {
+	// MapWorkers and ReduceWorkers are the numbers of goroutines of the Pools that map and reduce tasks run on, or 0
+	// if each task runs on a goroutine of its own, or on some other Executor.
+	MapWorkers    int `json:"mapWorkers"`
+	ReduceWorkers int `json:"reduceWorkers"`
+
+	CollectBuffer   int           `json:"collectBuffer"`
+	Collectors      int           `json:"collectors"`
+	OutputBuffer    int           `json:"outputBuffer"`
+	EmitBatch       int           `json:"emitBatch"`
+	MaxValuesPerKey int           `json:"maxValuesPerKey"`
+	DivergenceLimit int           `json:"divergenceLimit"`
+	TaskTimeout     time.Duration `json:"taskTimeout"`
+	StallTimeout    time.Duration `json:"stallTimeout"`
+	KeyTTL          time.Duration `json:"keyTTL"`
+	// MemoryPressureSpill is the high-water percentage of WithMemoryPressureSpill, or 0 if it isn't set.
+	MemoryPressureSpill int `json:"memoryPressureSpill"`
+	// MaxErrors is -1 unless WithMaxErrors is set.
+	MaxErrors int `json:"maxErrors"`
+	// InputSampleRate and InputSampleSeed are those of WithInputSampleRate, with a rate of 1 if it isn't set.
+	InputSampleRate float64 `json:"inputSampleRate"`
+	InputSampleSeed int64   `json:"inputSampleSeed"`
+
+	// MapRetries is the number of WithMapRetries, and the RetryBackoff fields the schedule of WithRetryBackoff, all 0
+	// if retries aren't delayed.
+	MapRetries          int           `json:"mapRetries"`
+	RetryBackoffInitial time.Duration `json:"retryBackoffInitial"`
+	RetryBackoffFactor  float64       `json:"retryBackoffFactor"`
+	RetryBackoffMax     time.Duration `json:"retryBackoffMax"`
+
+	// The policies are named after their constants, e.g. "PanicError" or "DropNewest". EmptyReduce is "DropKey",
+	// "KeepEmpty" or "Sentinel", with the sentinel value in EmptyReduceSentinel.
+	PanicPolicy         string `json:"panicPolicy"`
+	DropPolicy          string `json:"dropPolicy"`
+	PartialReduce       string `json:"partialReduce"`
+	EmptyReduce         string `json:"emptyReduce"`
+	EmptyReduceSentinel string `json:"emptyReduceSentinel,omitempty"`
+
+	// CombineIdentity is the value of WithCombineIdentity, or nil if it isn't set.
+	CombineIdentity *string `json:"combineIdentity,omitempty"`
+
+	ReduceMemo         bool   `json:"reduceMemo"`
+	LazyReduceInput    bool   `json:"lazyReduceInput"`
+	DetectKeyCollision bool   `json:"detectKeyCollision"`
+	SkipFailedInputs   bool   `json:"skipFailedInputs"`
+	DryRun             bool   `json:"dryRun"`
+	InsertionOrder     bool   `json:"insertionOrder"`
+	ProfilerLabels     bool   `json:"profilerLabels"`
+	BalancedReduce     bool   `json:"balancedReduce"`
+	Sequential         bool   `json:"sequential"`
+	ValueSet           bool   `json:"valueSet"`
+	OutputDir          string `json:"outputDir,omitempty"`
+
+	// Hooks names, in sorted order, the options that were given a function or interface value, such as
+	// "WithFinalizer" or "WithSink".
+	Hooks []string `json:"hooks,omitempty"`
+}
+
+// The names of the policies, for ConfigSnapshot.
+var (
+	panicPolicyNames         = [...]string{PanicError: "PanicError", PanicFail: "PanicFail", PanicSkip: "PanicSkip"}
+	dropPolicyNames          = [...]string{Block: "Block", DropNewest: "DropNewest", DropOldest: "DropOldest"}
+	partialReducePolicyNames = [...]string{Discard: "Discard", Include: "Include"}
+)
+
+// Config returns a snapshot of the configuration of the jobs the Runner runs, given no options of their own.
+func (r *Runner) Config() ConfigSnapshot {
+	return newConfig(r.options()).snapshot()
+}
+
+// snapshot returns the ConfigSnapshot of cfg.
+func (cfg *config) snapshot() ConfigSnapshot {
+	s := ConfigSnapshot{
+		MapWorkers:          poolSize(cfg.mapExecutor),
+		ReduceWorkers:       poolSize(cfg.reduceExecutor),
+		CollectBuffer:       cfg.collectBuffer,
+		Collectors:          cfg.collectors,
+		OutputBuffer:        cfg.outputBuffer,
+		EmitBatch:           cfg.emitBatch,
+		MaxValuesPerKey:     cfg.maxValuesPerKey,
+		DivergenceLimit:     cfg.divergenceLimit,
+		TaskTimeout:         cfg.taskTimeout,
+		StallTimeout:        cfg.stallTimeout,
+		KeyTTL:              cfg.keyTTL,
+		MemoryPressureSpill: cfg.memorySpill,
+		MaxErrors:           cfg.maxErrors,
+		InputSampleRate:     1,
+		MapRetries:          cfg.mapRetries,
+		RetryBackoffInitial: cfg.retryBackoff.initial,
+		RetryBackoffFactor:  cfg.retryBackoff.factor,
+		RetryBackoffMax:     cfg.retryBackoff.max,
+		PanicPolicy:         enumName(panicPolicyNames[:], cfg.panicPolicy),
+		DropPolicy:          enumName(dropPolicyNames[:], cfg.dropPolicy),
+		PartialReduce:       enumName(partialReducePolicyNames[:], cfg.partialReduce),
+		EmptyReduce:         "DropKey",
+		ReduceMemo:          cfg.reduceMemo,
+		LazyReduceInput:     cfg.lazyReduceInput,
+		DetectKeyCollision:  cfg.detectKeyCollision,
+		SkipFailedInputs:    cfg.skipFailedInputs,
+		DryRun:              cfg.dryRun,
+		InsertionOrder:      cfg.insertionOrder,
+		ProfilerLabels:      cfg.profilerLabels,
+		BalancedReduce:      cfg.balancedReduce,
+		Sequential:          cfg.sequential,
+		ValueSet:            cfg.valueSet,
+		OutputDir:           cfg.outputDir,
+	}
+	if cfg.sample != nil {
+		s.InputSampleRate, s.InputSampleSeed = cfg.sample.rate, cfg.sample.seed
+	}
+	if cfg.combineIdentity != nil {
+		identity := cfg.combineIdentity[0]
+		s.CombineIdentity = &identity
+	}
+	switch {
+	case cfg.emptyReduce.sentinel != nil:
+		s.EmptyReduce, s.EmptyReduceSentinel = "Sentinel", cfg.emptyReduce.sentinel[0]
+	case cfg.emptyReduce.keep:
+		s.EmptyReduce = "KeepEmpty"
+	}
+
+	for name, set := range map[string]bool{
+		"WithInputFilter":     cfg.inputFilter != nil,
+		"WithKeyNormalizer":   cfg.keyNormalizer != nil,
+		"WithGroupKey":        cfg.groupKeyFunc != nil,
+		"WithBarrier":         cfg.barrier != nil,
+		"WithReduceBroadcast": cfg.broadcast != nil,
+		"WithValueDedup":      cfg.valueEqual != nil,
+		"WithFinalizer":       cfg.finalizer != nil,
+		"WithResultValidator": cfg.resultValidator != nil,
+		"WithTracer":          cfg.tracer != nil,
+		"WithShuffler":        cfg.shuffler != nil,
+		"WithReduceTree":      cfg.reduceTree != nil,
+		"WithSink":            cfg.sink != nil,
+		"WithEmitCoalesce":    cfg.emitCoalesce != nil,
+		"WithRecorder":        cfg.recorder != nil,
+		"WithReducerRouter":   cfg.reducerRouter != nil,
+		"RegroupReduce":       cfg.regroupReduce != nil,
+		"WithPerKeyOutput":    cfg.outputName != nil,
+	} {
+		if set {
+			s.Hooks = append(s.Hooks, name)
+		}
+	}
+	sort.Strings(s.Hooks)
+	return s
+}
+
+// enumName returns the name of v in names, or unknown(v) for a value that has none.
+func enumName[T ~int](names []string, v T) string {
+	if v < 0 || int(v) >= len(names) || names[v] == "" {
+		return fmt.Sprintf("unknown(%d)", v)
+	}
+	return names[v]
+}
+
+// poolSize returns the number of goroutines of executor if it is a Pool, even one held back by a JobHandle, and
+// otherwise 0.
+func poolSize(executor Executor) int {
+	if p, ok := unwrapExecutor(executor).(*Pool); ok {
+		return p.size
+	}
+	return 0
+}
@@ -0,0 +1,59 @@
+package mapreduce
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRunnerConfig(t *testing.T) {
+	runner := NewRunner(4, 2, WithCollectBuffer(16), WithTaskTimeout(time.Minute), WithPanicPolicy(PanicFail),
+		WithEmptyReducePolicy(Sentinel("none")), WithFinalizer(func(r map[string][]string) map[string][]string {
+			return r
+		}), WithSink(NewMapSink()))
+	defer runner.Close()
+
+	snapshot := runner.Config()
+
+	expected := ConfigSnapshot{
+		MapWorkers:          4,
+		ReduceWorkers:       2,
+		CollectBuffer:       16,
+		TaskTimeout:         time.Minute,
+		MaxErrors:           -1,
+		InputSampleRate:     1,
+		PanicPolicy:         "PanicFail",
+		DropPolicy:          "Block",
+		PartialReduce:       "Discard",
+		EmptyReduce:         "Sentinel",
+		EmptyReduceSentinel: "none",
+		Hooks:               []string{"WithFinalizer", "WithSink"},
+	}
+	if !reflect.DeepEqual(expected, snapshot) {
+		t.Errorf("Expected <%+v>; Got <%+v>", expected, snapshot)
+	}
+
+	// It survives a round trip through JSON.
+	encoded, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	var decoded ConfigSnapshot
+	if err := json.Unmarshal(encoded, &decoded); err != nil || !reflect.DeepEqual(snapshot, decoded) {
+		t.Errorf("Expected <%+v>; Got <%+v>, <%v>", snapshot, decoded, err)
+	}
+}
+
+func TestConfigSnapshotUnknownPolicy(t *testing.T) {
+	snapshot := newConfig([]Option{WithPanicPolicy(PanicPolicy(42)), RegroupReduce(func(key string) string {
+		return key
+	}, SumIntReduce), WithPerKeyOutput(t.TempDir(), func(key string) string { return key })}).snapshot()
+
+	if snapshot.PanicPolicy != "unknown(42)" {
+		t.Errorf("Expected <unknown(42)>; Got <%v>", snapshot.PanicPolicy)
+	}
+	if expected := []string{"RegroupReduce", "WithPerKeyOutput"}; !reflect.DeepEqual(expected, snapshot.Hooks) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, snapshot.Hooks)
+	}
+}
@@ -0,0 +1,106 @@
+package mapreduce
+
+import (
+	"bufio"
+	"math"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+const (
+	// spillSampleInterval is the number of map output records between the collector's samples of the heap under
+	// WithMemoryPressureSpill. Reading the runtime's memory statistics stops the world, so it isn't done for each.
+	spillSampleInterval = 1024
+	// spillRunSize is the run size of the DiskShuffler that map output is spilled to.
+	spillRunSize = 1 << 16
+)
+
+// WithMemoryPressureSpill has the job move its map output to disk if, while it is being collected, the heap grows
+// past highWaterPercent of the memory available to the process: the Go memory limit, if one is set with GOMEMLIMIT
+// or debug.SetMemoryLimit, and otherwise the host's memory, as far as it can be told. From then on the job carries on
+// as if it had been given a DiskShuffler with WithShuffler, with the same effects on other options, so a job whose
+// intermediate data fits in memory keeps it there, and one whose data doesn't can still finish. The heap is sampled
+// every so many records, as reading it briefly stops the program. Stats.Spilled reports whether the job spilled. It
+// has no effect on jobs that already have a Shuffler, or if the memory available can't be told.
+func WithMemoryPressureSpill(highWaterPercent int) Option {
+	return func(cfg *config) {
+		cfg.memorySpill = highWaterPercent
+	}
+}
+
+// shuffler returns the Shuffler that the job's map output goes to, if any: that of WithShuffler, or the one it was
+// spilled to under WithMemoryPressureSpill.
+func (j *job) shuffler() Shuffler {
+	if j.cfg.shuffler != nil {
+		return j.cfg.shuffler
+	}
+	if j.spill != nil {
+		return j.spill
+	}
+	return nil
+}
+
+// underMemoryPressure reports whether it is time for the map output collected so far to be spilled under
+// WithMemoryPressureSpill, sampling the heap every spillSampleInterval calls.
+func (j *job) underMemoryPressure() bool {
+	if j.cfg.memorySpill <= 0 || j.phase != mapPhase || j.shuffler() != nil {
+		return false
+	}
+	j.spillSamples++
+	if j.spillSamples%spillSampleInterval != 0 {
+		return false
+	}
+	limit := availableMemory()
+	if limit == 0 {
+		return false
+	}
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return float64(stats.HeapAlloc) >= float64(limit)*float64(j.cfg.memorySpill)/100
+}
+
+// spillResults moves results to a new DiskShuffler, which the rest of the map output then goes to.
+func (j *job) spillResults(results map[string][]string) error {
+	s, err := NewDiskShuffler("", spillRunSize)
+	if err != nil {
+		return err
+	}
+	j.spill = s
+	j.stats.Spilled = true
+	for key, values := range results {
+		if err := s.Add(MRInput{Key: key, Values: values}); err != nil {
+			return err
+		}
+		delete(results, key)
+	}
+	return nil
+}
+
+// availableMemory returns the Go memory limit, if one is set, and otherwise the host's total memory as reported by
+// /proc/meminfo, or 0 if there is none.
+func availableMemory() uint64 {
+	if limit := debug.SetMemoryLimit(-1); limit != math.MaxInt64 {
+		return uint64(limit)
+	}
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// The line reads e.g. "MemTotal:       16318412 kB".
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 3 && fields[0] == "MemTotal:" && fields[2] == "kB" {
+			kb, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0
+			}
+			return kb << 10
+		}
+	}
+	return 0
+}
@@ -0,0 +1,26 @@
+package mapreduce
+
+import (
+	"reflect"
+	"runtime/debug"
+	"testing"
+)
+
+func TestWithMemoryPressureSpill(t *testing.T) {
+	// With a memory limit of 256MiB, the heap of any test is well past 1% of it, and well short of all of it.
+	defer debug.SetMemoryLimit(debug.SetMemoryLimit(256 << 20))
+	input := numberedInputs(5000)
+	expected := MapReduce(input, wordMap, countReduce)
+
+	result, stats := MapReduceWithStats(input, wordMap, countReduce, WithMemoryPressureSpill(1))
+	if !stats.Spilled {
+		t.Errorf("Expected the map output to be spilled")
+	}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected the same result as without spilling; Got <%d> keys", len(result))
+	}
+
+	if _, stats := MapReduceWithStats(input, wordMap, countReduce, WithMemoryPressureSpill(100)); stats.Spilled {
+		t.Errorf("Expected nothing spilled below the high-water mark")
+	}
+}
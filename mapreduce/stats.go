@@ -0,0 +1,42 @@
+package mapreduce
+
+// Stats describes a completed map-reduce run.
+type Stats struct {
+	// MapTasks and ReduceTasks are the number of map and reduce functions that were run.
+	MapTasks    int
+	ReduceTasks int
+
+	// PeakIntermediateBytes estimates the most data the collector held at once, as the sum of the lengths of the
+	// keys and values it had gathered. It ignores Go's per-string and per-slice overhead, so it understates real
+	// memory use, but it scales with it and is cheap to compute.
+	PeakIntermediateBytes int64
+
+	// EmitBlocks is the number of times a map function's output had to wait for the collector to be ready for it.
+	// It is a sign that the collector, which handles one record at a time, is the job's bottleneck.
+	EmitBlocks int
+
+	// MapRecords and ReduceRecords are the numbers of records the map and reduce functions sent to the collector,
+	// not counting warnings and errors. Records in a batch (see WithEmitBatch) count individually.
+	MapRecords    int
+	ReduceRecords int
+
+	// CollectBuffer is the capacity of the channel the collector receives on, as set by WithCollectBuffer.
+	// PeakCollectBacklog is the most messages seen waiting in it, sampled each time the collector took one out, with
+	// a batch counting as one message. A peak close to the capacity suggests that a larger buffer would help; one
+	// well below it, that a smaller one would do.
+	CollectBuffer      int
+	PeakCollectBacklog int
+
+	// DroppedRecords is the number of reduce output records a streaming job discarded under its DropPolicy.
+	DroppedRecords int
+
+	// EvictedKeys is the number of keys evicted from the result under WithKeyTTL.
+	EvictedKeys int
+
+	// Spilled reports whether the map output was spilled to disk under WithMemoryPressureSpill.
+	Spilled bool
+
+	// FailedInputs are the inputs that were skipped under WithSkipFailedInputs, in the order their failures reached
+	// the collector.
+	FailedInputs []MRInput
+}
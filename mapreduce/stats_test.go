@@ -0,0 +1,66 @@
+package mapreduce
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// numberedInputs returns n inputs, each holding a single distinct, fixed-width word.
+func numberedInputs(n int) []MRInput {
+	input := make([]MRInput, n)
+	for i := range input {
+		input[i] = MRInput{Key: fmt.Sprint(i), Values: []string{fmt.Sprintf("word%06d", i)}}
+	}
+	return input
+}
+
+func TestMapReduceWithStats(t *testing.T) {
+	_, small := MapReduceWithStats(numberedInputs(100), wordMap, countReduce)
+	_, large := MapReduceWithStats(numberedInputs(1000), wordMap, countReduce)
+
+	if small.MapTasks != 100 || small.ReduceTasks != 100 {
+		t.Errorf("Expected 100 map and reduce tasks; Got <%+v>", small)
+	}
+	// Each intermediate entry is a 10 byte word plus a 1 byte "1" value.
+	if small.PeakIntermediateBytes != 100*11 {
+		t.Errorf("Expected peak of <%d> bytes; Got <%d>", 100*11, small.PeakIntermediateBytes)
+	}
+	if large.PeakIntermediateBytes != 10*small.PeakIntermediateBytes {
+		t.Errorf("Expected peak to scale with input size; Got <%d> for 100 inputs and <%d> for 1000",
+			small.PeakIntermediateBytes, large.PeakIntermediateBytes)
+	}
+}
+
+func TestStatsEmitBlocks(t *testing.T) {
+	// A collector that dawdles before each receive leaves the mappers waiting on it.
+	slowCollector := withCollectStep(func() { time.Sleep(100 * time.Microsecond) })
+
+	_, stats := MapReduceWithStats(numberedInputs(50), wordMap, countReduce, slowCollector)
+
+	if stats.EmitBlocks == 0 {
+		t.Errorf("Expected blocked emits to be counted; Got <%+v>", stats)
+	}
+}
+
+func TestStatsRecords(t *testing.T) {
+	// Each input is two words, for 200 map output records.
+	input := numberedInputs(100)
+	for i := range input {
+		input[i].Values[0] += " shared"
+	}
+	slowCollector := withCollectStep(func() { time.Sleep(10 * time.Microsecond) })
+
+	result, stats := MapReduceWithStats(input, wordMap, countReduce, WithCollectBuffer(16), slowCollector)
+
+	if stats.MapRecords != 200 {
+		t.Errorf("Expected <200> map records; Got <%d>", stats.MapRecords)
+	}
+	if stats.ReduceRecords != len(result) {
+		t.Errorf("Expected <%d> reduce records; Got <%d>", len(result), stats.ReduceRecords)
+	}
+	if stats.CollectBuffer != 16 || stats.PeakCollectBacklog == 0 || stats.PeakCollectBacklog > 16 {
+		t.Errorf("Expected a backlog of up to 16 in a buffer of 16; Got <%d> in <%d>", stats.PeakCollectBacklog,
+			stats.CollectBuffer)
+	}
+}
@@ -0,0 +1,158 @@
+package mapreduce
+
+import "context"
+
+// DropPolicy determines what a streaming job does with reduce output that its consumer isn't ready to receive.
+type DropPolicy int
+
+const (
+	// Block waits for the consumer, never losing output. It is the default.
+	Block DropPolicy = iota
+	// DropNewest discards the record that doesn't fit in the output buffer.
+	DropNewest
+	// DropOldest discards the oldest buffered record to make room for the new one. Without an output buffer there is
+	// nothing older to discard, so it behaves like DropNewest.
+	DropOldest
+)
+
+// Stream is a running map-reduce job whose reduce output is delivered on a channel as it is produced rather than
+// gathered into a map.
+type Stream struct {
+	// C receives each record emitted by the reducers. It is closed once the job has completed.
+	C <-chan MRInput
+
+	resultChl chan outcome
+	out       *outcome
+}
+
+// MapReduceStream starts a map-reduce job like MapReduce, but returns as soon as the job has started. The reducers'
+// output is sent on the returned Stream's channel, which the caller must drain, e.g. with Drain, or the job never
+// completes (see WithDropPolicy for consumers that can't always keep up). A caller that may abandon the output should
+// use MapReduceStreamContext instead.
+func MapReduceStream(input []MRInput, mapFunc MapFunc, reduceFunc ReduceFunc, opts ...Option) *Stream {
+	return MapReduceStreamContext(context.TODO(), input, mapFunc, reduceFunc, opts...)
+}
+
+// MapReduceStreamContext is like MapReduceStream, but stops the job if ctx is cancelled, even while it is blocked
+// sending output nobody is receiving. C is then closed without the rest of the output, and Wait returns an error
+// wrapping ctx.Err(), so cancelling ctx is enough to release an abandoned job without draining it.
+func MapReduceStreamContext(ctx context.Context, input []MRInput, mapFunc MapFunc, reduceFunc ReduceFunc,
+	opts ...Option) *Stream {
+	cfg := newConfig(opts)
+	stream := make(chan MRInput, cfg.outputBuffer)
+	s := &Stream{C: stream, resultChl: make(chan outcome, 1)}
+	go master(ctx, s.resultChl, &job{cfg: cfg, stream: stream}, []MapFunc{mapFunc}, reduceFunc, input)
+	return s
+}
+
+// Drain receives and discards everything sent on ch until it is closed, e.g. to let a streaming job whose remaining
+// output isn't wanted run to completion.
+func Drain(ch <-chan MRInput) {
+	for range ch {
+	}
+}
+
+// StreamOutSharded divides the job's output among n channels, so that n consumers can process it in parallel, and
+// returns them. Records are assigned to shards by PartitionOf their key, so every record of a key goes to the same
+// shard. The shards are fed from C in turn, so one consumer that falls behind holds up the rest, and all of them must
+// be drained for the job to complete. It must be called at most once, before anything has been received from C, and
+// C must not be used after it. The shards are closed once the job has completed.
+func (s *Stream) StreamOutSharded(n int) []<-chan MRInput {
+	shards := make([]chan MRInput, n)
+	out := make([]<-chan MRInput, n)
+	for i := range shards {
+		shards[i] = make(chan MRInput)
+		out[i] = shards[i]
+	}
+	go func() {
+		for kv := range s.C {
+			shards[PartitionOf(kv.Key, n)] <- kv
+		}
+		for _, shard := range shards {
+			close(shard)
+		}
+	}()
+	return out
+}
+
+// Wait waits for the job to complete and returns its warnings, statistics, and error, as MapReduceWithWarnings,
+// MapReduceWithStats and TryMapReduce would. Output that hasn't been received from C remains there, but with the
+// Block policy the job can't complete until C has been drained, so Wait must not be called before then.
+func (s *Stream) Wait() (warnings []string, stats Stats, err error) {
+	if s.out == nil {
+		out := <-s.resultChl
+		s.out = &out
+	}
+	return s.out.warnings, s.out.stats, s.out.err
+}
+
+// streamResults is the streaming counterpart of collectResults: it sends each record on j.stream, subject to the
+// job's drop policy, instead of grouping them.
+func (j *job) streamResults(ctx context.Context, collectChl chan MRInput, numProcs int, doneChl chan struct{}) {
+	var stream func(result MRInput)
+	stream = func(result MRInput) {
+		for _, batchResult := range result.batch {
+			stream(batchResult)
+		}
+		if result.batch != nil {
+			return
+		}
+		if result.ctl != nil {
+			j.handleControl(result.ctl)
+			return
+		}
+		j.countRecord()
+		result.Key = j.groupKey(result.Key)
+		j.send(ctx, result)
+	}
+	for numProcs > 0 {
+		select {
+		case result := <-collectChl:
+			j.sampleBacklog(collectChl)
+			stream(result)
+		case <-doneChl:
+			numProcs--
+		case <-ctx.Done():
+			go drain(collectChl, numProcs, doneChl)
+			return
+		}
+	}
+	// As in collectResults, output can still be waiting once every task is done.
+	drainReady(collectChl, stream)
+}
+
+// send delivers kv on j.stream according to the configured DropPolicy, giving up if ctx is cancelled while it waits.
+func (j *job) send(ctx context.Context, kv MRInput) {
+	policy := j.cfg.dropPolicy
+	if policy == DropOldest && cap(j.stream) == 0 {
+		policy = DropNewest
+	}
+
+	switch policy {
+	case DropNewest:
+		select {
+		case j.stream <- kv:
+		default:
+			j.stats.DroppedRecords++
+		}
+	case DropOldest:
+		for {
+			select {
+			case j.stream <- kv:
+				return
+			default:
+			}
+			// Make room by discarding the oldest buffered record, unless the consumer just got to it first.
+			select {
+			case <-j.stream:
+				j.stats.DroppedRecords++
+			default:
+			}
+		}
+	default:
+		select {
+		case j.stream <- kv:
+		case <-ctx.Done():
+		}
+	}
+}
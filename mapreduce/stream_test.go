@@ -0,0 +1,117 @@
+package mapreduce
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestMapReduceStream(t *testing.T) {
+	input := numberedInputs(100)
+	expected := MapReduce(input, wordMap, countReduce)
+
+	s := MapReduceStream(input, wordMap, countReduce)
+	result := make(map[string][]string)
+	for kv := range s.C {
+		result[kv.Key] = append(result[kv.Key], kv.Values...)
+	}
+	_, stats, err := s.Wait()
+
+	if err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+	if stats.DroppedRecords != 0 {
+		t.Errorf("Expected nothing dropped; Got <%d>", stats.DroppedRecords)
+	}
+}
+
+func TestMapReduceStreamDropPolicies(t *testing.T) {
+	for _, policy := range []DropPolicy{DropNewest, DropOldest} {
+		// Nothing reads the output until the job is done, so all but the buffered records must be dropped.
+		s := MapReduceStream(numberedInputs(100), wordMap, countReduce, WithOutputBuffer(2), WithDropPolicy(policy))
+		_, stats, err := s.Wait()
+
+		if err != nil {
+			t.Fatalf("Unexpected error <%v>", err)
+		}
+		if stats.DroppedRecords != 98 {
+			t.Errorf("Policy <%d>: Expected 98 dropped records; Got <%d>", policy, stats.DroppedRecords)
+		}
+		received := 0
+		for range s.C {
+			received++
+		}
+		if received != 2 {
+			t.Errorf("Policy <%d>: Expected the 2 buffered records; Got <%d>", policy, received)
+		}
+	}
+}
+
+func TestDrain(t *testing.T) {
+	s := MapReduceStream(numberedInputs(100), wordMap, countReduce)
+	<-s.C
+	Drain(s.C)
+
+	if _, stats, err := s.Wait(); err != nil || stats.ReduceTasks != 100 {
+		t.Errorf("Expected the job to complete; Got <%+v>, <%v>", stats, err)
+	}
+}
+
+func TestMapReduceStreamContextCancelled(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := MapReduceStreamContext(ctx, numberedInputs(100), wordMap, countReduce)
+	// Take one record and abandon the rest, leaving the job blocked on the output channel.
+	<-s.C
+	cancel()
+	_, _, err := s.Wait()
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected <%v>; Got <%v>", context.Canceled, err)
+	}
+	// The job's goroutines, including the reducers it had started, must all exit without anything draining C.
+	waitForGoroutines(t, before)
+}
+
+func TestStreamOutSharded(t *testing.T) {
+	s := MapReduceStream(numberedInputs(100), wordMap, countReduce)
+	shards := s.StreamOutSharded(4)
+
+	var mu sync.Mutex
+	shardOf := make(map[string]int)
+	var wg sync.WaitGroup
+	for i, shard := range shards {
+		wg.Add(1)
+		go func(i int, shard <-chan MRInput) {
+			defer wg.Done()
+			for kv := range shard {
+				mu.Lock()
+				if prev, ok := shardOf[kv.Key]; ok && prev != i {
+					t.Errorf("Key <%s> on shards <%d> and <%d>", kv.Key, prev, i)
+				}
+				shardOf[kv.Key] = i
+				mu.Unlock()
+			}
+		}(i, shard)
+	}
+	wg.Wait()
+
+	if _, _, err := s.Wait(); err != nil {
+		t.Fatalf("Unexpected error <%v>", err)
+	}
+	if len(shardOf) != 100 {
+		t.Errorf("Expected all 100 keys; Got <%d>", len(shardOf))
+	}
+	for key, shard := range shardOf {
+		if shard != PartitionOf(key, 4) {
+			t.Errorf("Expected key <%s> on shard <%d>; Got <%d>", key, PartitionOf(key, 4), shard)
+		}
+	}
+}
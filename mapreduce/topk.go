@@ -0,0 +1,78 @@
+package mapreduce
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// GlobalTopK returns the k keys of result with the highest scores, as computed by score from each key's values, with
+// their values, highest score first; keys with equal scores are in key order. It is meant for use on a completed
+// result, such as in a WithFinalizer function, e.g. to find the ten most frequent words of a word count. It keeps the
+// k best keys seen so far in a min-heap rather than sorting the whole result, so it takes time proportional to the
+// number of keys times log k. It returns nil if k is less than 1.
+func GlobalTopK(result map[string][]string, k int, score func(values []string) float64) []MRInput {
+	return GlobalTopKBy(result, k, score, lexical)
+}
+
+// GlobalTopKBy is like GlobalTopK, but breaks ties between keys of equal scores with tieBreak, which reports whether
+// key a goes before key b. Both which keys make the cut and their order then depend only on score and tieBreak, not
+// on the order in which the result's keys are visited, as long as tieBreak puts any two distinct keys in an order.
+func GlobalTopKBy(result map[string][]string, k int, score func(values []string) float64,
+	tieBreak func(a, b string) bool) []MRInput {
+	if k < 1 {
+		return nil
+	}
+	h := &scoredHeap{tieBreak: tieBreak}
+	for key, values := range result {
+		s := scoredKey{key: key, score: score(values)}
+		if h.Len() < k {
+			heap.Push(h, s)
+		} else if h.less(h.keys[0], s) {
+			h.keys[0] = s
+			heap.Fix(h, 0)
+		}
+	}
+
+	sort.Slice(h.keys, func(a, b int) bool { return h.less(h.keys[b], h.keys[a]) })
+	top := make([]MRInput, len(h.keys))
+	for i, s := range h.keys {
+		top[i] = MRInput{Key: s.key, Values: result[s.key]}
+	}
+	return top
+}
+
+// scoredKey is a key of a result with its score, for GlobalTopK.
+type scoredKey struct {
+	key   string
+	score float64
+}
+
+// scoredHeap is a min-heap of scored keys, with the worst at the top: the lowest score, or of equal scores, the last
+// key by tieBreak.
+type scoredHeap struct {
+	keys     []scoredKey
+	tieBreak func(a, b string) bool
+}
+
+// less reports whether a ranks below b.
+func (h *scoredHeap) less(a, b scoredKey) bool {
+	if a.score != b.score {
+		return a.score < b.score
+	}
+	return h.tieBreak(b.key, a.key)
+}
+
+// lexical is the default tie-breaker of the ranking helpers, which puts strings in lexical order.
+func lexical(a, b string) bool {
+	return a < b
+}
+
+func (h *scoredHeap) Len() int           { return len(h.keys) }
+func (h *scoredHeap) Less(a, b int) bool { return h.less(h.keys[a], h.keys[b]) }
+func (h *scoredHeap) Swap(a, b int)      { h.keys[a], h.keys[b] = h.keys[b], h.keys[a] }
+func (h *scoredHeap) Push(x interface{}) { h.keys = append(h.keys, x.(scoredKey)) }
+func (h *scoredHeap) Pop() interface{} {
+	s := h.keys[len(h.keys)-1]
+	h.keys = h.keys[:len(h.keys)-1]
+	return s
+}
@@ -0,0 +1,69 @@
+package mapreduce
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestGlobalTopK(t *testing.T) {
+	counts := map[string][]string{
+		"the": {"12"}, "dog": {"3"}, "cat": {"5"}, "a": {"9"}, "of": {"5"}, "mouse": {"1"}, "and": {"7"},
+	}
+	count := func(values []string) float64 {
+		n, _ := strconv.Atoi(values[0])
+		return float64(n)
+	}
+
+	top := GlobalTopK(counts, 4, count)
+
+	// "cat" and "of" tie for fourth place; "cat" comes first.
+	expected := []MRInput{
+		{Key: "the", Values: []string{"12"}},
+		{Key: "a", Values: []string{"9"}},
+		{Key: "and", Values: []string{"7"}},
+		{Key: "cat", Values: []string{"5"}},
+	}
+	if !reflect.DeepEqual(expected, top) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, top)
+	}
+	if all := GlobalTopK(counts, 100, count); len(all) != len(counts) {
+		t.Errorf("Expected all <%d> keys; Got <%d>", len(counts), len(all))
+	}
+	if none := GlobalTopK(counts, 0, count); none != nil {
+		t.Errorf("Expected nil; Got <%v>", none)
+	}
+}
+
+func TestGlobalTopKTies(t *testing.T) {
+	// Every key scores the same, so the top keys are decided by the tie-breaker alone.
+	result := make(map[string][]string)
+	for i := 0; i < 100; i++ {
+		result[strconv.Itoa(i)] = []string{"1"}
+	}
+	same := func(values []string) float64 { return 1 }
+	keysOf := func(top []MRInput) []string {
+		var keys []string
+		for _, kv := range top {
+			keys = append(keys, kv.Key)
+		}
+		return keys
+	}
+
+	expected := []string{"0", "1", "10"}
+	for i := 0; i < 20; i++ {
+		if top := keysOf(GlobalTopK(result, 3, same)); !reflect.DeepEqual(top, expected) {
+			t.Fatalf("Expected <%v>; Got <%v>", expected, top)
+		}
+	}
+
+	numerically := func(a, b string) bool {
+		x, _ := strconv.Atoi(a)
+		y, _ := strconv.Atoi(b)
+		return x > y
+	}
+	expected = []string{"99", "98", "97"}
+	if top := keysOf(GlobalTopKBy(result, 3, same, numerically)); !reflect.DeepEqual(top, expected) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, top)
+	}
+}
@@ -0,0 +1,57 @@
+package mapreduce
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// KeyAttribute is the span attribute under which a task's input key is recorded.
+const KeyAttribute = "mapreduce.key"
+
+// Tracer creates the spans that trace a job; see WithTracer. It is deliberately tiny, so that the package doesn't
+// depend on any tracing library. Building with the "otel" tag adds OTelTracer, which adapts OpenTelemetry to it.
+type Tracer interface {
+	// Start starts a span called name, as a child of the span in ctx if there is one, with the given attributes. It
+	// returns a context holding the new span, and a function that ends it.
+	Start(ctx context.Context, name string, attrs map[string]string) (context.Context, func())
+}
+
+// startSpan starts a span with the job's tracer, recording key as the KeyAttribute unless it is empty. Without a
+// tracer it returns ctx unchanged and a no-op.
+func (j *job) startSpan(ctx context.Context, name, key string) (context.Context, func()) {
+	if j.cfg.tracer == nil {
+		return ctx, func() {}
+	}
+	var attrs map[string]string
+	if key != "" {
+		attrs = map[string]string{KeyAttribute: key}
+	}
+	return j.cfg.tracer.Start(ctx, name, attrs)
+}
+
+// traceTask calls task, which signals on the doneChl it is given when it is done, within a span. The span is ended
+// before doneChl is signaled, so that every task's span has ended by the time the job completes.
+func (j *job) traceTask(ctx context.Context, name, key string, doneChl chan struct{}, task func(chan struct{})) {
+	if j.cfg.tracer == nil {
+		task(doneChl)
+		return
+	}
+
+	_, endSpan := j.startSpan(ctx, name, key)
+	taskDoneChl := make(chan struct{}, 1)
+	task(taskDoneChl)
+	<-taskDoneChl
+	endSpan()
+	doneChl <- struct{}{}
+}
+
+// labelTask runs task under a pprof label naming its phase, if the job has WithProfilerLabels.
+func (j *job) labelTask(ctx context.Context, phase string, task func()) {
+	if !j.cfg.profilerLabels {
+		task()
+		return
+	}
+	pprof.Do(ctx, pprof.Labels("phase", phase), func(context.Context) {
+		task()
+	})
+}
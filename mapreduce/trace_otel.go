@@ -0,0 +1,28 @@
+//go:build otel
+
+package mapreduce
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// OTelTracer returns a Tracer that records spans with tracers from tp, e.g. WithTracer(OTelTracer(tp)).
+func OTelTracer(tp trace.TracerProvider) Tracer {
+	return otelTracer{tracer: tp.Tracer("github.com/youngkin/gomapreduce/mapreduce")}
+}
+
+type otelTracer struct {
+	tracer trace.Tracer
+}
+
+func (t otelTracer) Start(ctx context.Context, name string, attrs map[string]string) (context.Context, func()) {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for k, v := range attrs {
+		kvs = append(kvs, attribute.String(k, v))
+	}
+	ctx, span := t.tracer.Start(ctx, name, trace.WithAttributes(kvs...))
+	return ctx, func() { span.End() }
+}
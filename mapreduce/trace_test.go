@@ -0,0 +1,116 @@
+package mapreduce
+
+import (
+	"context"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type recordedSpan struct {
+	id, parent int
+	name       string
+	attrs      map[string]string
+	ended      bool
+}
+
+type spanIDKey struct{}
+
+// spanRecorder is an in-memory Tracer.
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (r *spanRecorder) Start(ctx context.Context, name string, attrs map[string]string) (context.Context, func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	parent, _ := ctx.Value(spanIDKey{}).(int)
+	span := &recordedSpan{id: len(r.spans) + 1, parent: parent, name: name, attrs: attrs}
+	r.spans = append(r.spans, span)
+	return context.WithValue(ctx, spanIDKey{}, span.id), func() {
+		r.mu.Lock()
+		span.ended = true
+		r.mu.Unlock()
+	}
+}
+
+func TestMapReduceTracer(t *testing.T) {
+	input := []MRInput{
+		{Key: "line1", Values: []string{"the dog"}},
+		{Key: "line2", Values: []string{"the cat"}},
+	}
+	recorder := &spanRecorder{}
+
+	MapReduce(input, wordMap, countReduce, WithTracer(recorder))
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if len(recorder.spans) == 0 || recorder.spans[0].name != "mapreduce.job" || recorder.spans[0].parent != 0 {
+		t.Fatalf("Expected a root job span first; Got <%+v>", recorder.spans)
+	}
+	job := recorder.spans[0]
+	keys := map[string][]string{}
+	for _, span := range recorder.spans {
+		if !span.ended {
+			t.Errorf("Expected span <%s> to have ended", span.name)
+		}
+		if span == job {
+			continue
+		}
+		if span.parent != job.id {
+			t.Errorf("Expected span <%s> to be a child of the job span", span.name)
+		}
+		keys[span.name] = append(keys[span.name], span.attrs[KeyAttribute])
+	}
+	for _, k := range keys {
+		sort.Strings(k)
+	}
+	if len(keys) != 2 || len(keys["mapreduce.map"]) != 2 || len(keys["mapreduce.reduce"]) != 3 {
+		t.Fatalf("Expected 2 map and 3 reduce spans; Got <%v>", keys)
+	}
+	if keys["mapreduce.map"][0] != "line1" || keys["mapreduce.reduce"][0] != "cat" {
+		t.Errorf("Expected spans to record task keys; Got <%v>", keys)
+	}
+}
+
+// goroutineLabels returns the goroutine profile, which lists the labels of each goroutine.
+func goroutineLabels() string {
+	var b strings.Builder
+	pprof.Lookup("goroutine").WriteTo(&b, 1)
+	return b.String()
+}
+
+func TestWithProfilerLabels(t *testing.T) {
+	var mu sync.Mutex
+	var mapProfile, reduceProfile string
+	mapFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		mu.Lock()
+		mapProfile = goroutineLabels()
+		mu.Unlock()
+		wordMap(input, collectChl, doneChl)
+	}
+	reduceFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		mu.Lock()
+		reduceProfile = goroutineLabels()
+		mu.Unlock()
+		countReduce(input, collectChl, doneChl)
+	}
+	input := []MRInput{{Key: "line1", Values: []string{"the dog"}}}
+
+	MapReduce(input, mapFunc, reduceFunc, WithProfilerLabels())
+
+	if !strings.Contains(mapProfile, `"phase":"map"`) {
+		t.Errorf("Expected a goroutine labeled phase=map while mapping; Got <%s>", mapProfile)
+	}
+	if !strings.Contains(reduceProfile, `"phase":"reduce"`) {
+		t.Errorf("Expected a goroutine labeled phase=reduce while reducing; Got <%s>", reduceProfile)
+	}
+
+	MapReduce(input, mapFunc, reduceFunc)
+	if strings.Contains(mapProfile, `"phase"`) {
+		t.Errorf("Expected no labels without the option")
+	}
+}
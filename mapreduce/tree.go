@@ -0,0 +1,63 @@
+package mapreduce
+
+import (
+	"runtime"
+	"sync"
+)
+
+// treeReduce wraps reduceFunc so that it is given each key's values already combined into one by combineTree. A key
+// with no values gets identity, if it is set, and otherwise still no values.
+func treeReduce(combine func(a, b string) string, identity []string, reduceFunc ReduceFunc) ReduceFunc {
+	return func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		switch {
+		case len(input.Values) > 1:
+			input.Values = []string{combineTree(input.Values, combine)}
+		case len(input.Values) == 0 && identity != nil:
+			input.Values = []string{identity[0]}
+		}
+		reduceFunc(input, collectChl, doneChl)
+	}
+}
+
+// combineTree combines values pairwise, a level of the tree at a time, with the pairs of each level split between up
+// to GOMAXPROCS goroutines. Each combination is of neighbouring values, so their order is kept. values must not be
+// empty. A panic in combine is re-raised on the calling goroutine once the level has finished.
+func combineTree(values []string, combine func(a, b string) string) string {
+	workers := runtime.GOMAXPROCS(0)
+	for len(values) > 1 {
+		next := make([]string, (len(values)+1)/2)
+		chunk := (len(next) + workers - 1) / workers
+		var wg sync.WaitGroup
+		var once sync.Once
+		var panicked interface{}
+		for start := 0; start < len(next); start += chunk {
+			end := start + chunk
+			if end > len(next) {
+				end = len(next)
+			}
+			wg.Add(1)
+			go func(start, end int) {
+				defer wg.Done()
+				defer func() {
+					if r := recover(); r != nil {
+						once.Do(func() { panicked = r })
+					}
+				}()
+				for i := start; i < end; i++ {
+					if 2*i+1 < len(values) {
+						next[i] = combine(values[2*i], values[2*i+1])
+					} else {
+						// An odd value out goes up a level as it is.
+						next[i] = values[2*i]
+					}
+				}
+			}(start, end)
+		}
+		wg.Wait()
+		if panicked != nil {
+			panic(panicked)
+		}
+		values = next
+	}
+	return values[0]
+}
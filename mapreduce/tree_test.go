@@ -0,0 +1,61 @@
+package mapreduce
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestWithReduceTree(t *testing.T) {
+	// Spreads the numbers 1 to 10000 over three keys.
+	var input []MRInput
+	for i := 1; i <= 10000; i++ {
+		input = append(input, MRInput{Key: strconv.Itoa(i % 3), Values: []string{strconv.Itoa(i)}})
+	}
+	add := func(a, b string) string {
+		x, _ := strconv.Atoi(a)
+		y, _ := strconv.Atoi(b)
+		return strconv.Itoa(x + y)
+	}
+
+	linear := MapReduce(input, IdentityMap, SumIntReduce)
+	tree := MapReduce(input, IdentityMap, IdentityReduce, WithReduceTree(add))
+
+	if !reflect.DeepEqual(linear, tree) {
+		t.Errorf("Expected <%v>; Got <%v>", linear, tree)
+	}
+}
+
+func TestCombineTreeKeepsOrder(t *testing.T) {
+	values := []string{"a", "b", "c", "d", "e", "f", "g"}
+	concat := func(a, b string) string { return a + b }
+
+	if got := combineTree(values, concat); got != "abcdefg" {
+		t.Errorf("Expected <abcdefg>; Got <%v>", got)
+	}
+}
+
+func TestWithCombineIdentity(t *testing.T) {
+	add := func(a, b string) string {
+		x, _ := strconv.Atoi(a)
+		y, _ := strconv.Atoi(b)
+		return strconv.Itoa(x + y)
+	}
+	// "empty" is emitted without values, so it reaches the reducer with none to combine.
+	input := []MRInput{{Key: "empty"}, {Key: "one", Values: []string{"1"}}, {Key: "many", Values: []string{"1", "2", "3"}}}
+	expected := map[string][]string{"empty": {"0"}, "one": {"1"}, "many": {"6"}}
+
+	tree := MapReduce(input, IdentityMap, IdentityReduce, WithReduceTree(add), WithCombineIdentity("0"))
+	if !reflect.DeepEqual(tree, expected) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, tree)
+	}
+	coalesced := MapReduce(input, IdentityMap, IdentityReduce, WithEmitCoalesce(add), WithCombineIdentity("0"))
+	if !reflect.DeepEqual(coalesced, expected) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, coalesced)
+	}
+
+	without := MapReduce(input, IdentityMap, IdentityReduce, WithReduceTree(add))
+	if len(without["empty"]) != 0 {
+		t.Errorf("Expected the key to keep no values without an identity; Got <%v>", without)
+	}
+}
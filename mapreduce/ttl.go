@@ -0,0 +1,80 @@
+package mapreduce
+
+import (
+	"container/list"
+	"time"
+)
+
+// WithKeyTTL keeps the job's result to the keys that are still active: a key of the reduce output that nothing has
+// been collected for in d is evicted from the result, along with its values, so that the result of a long-running
+// job with a changing set of keys doesn't keep growing. Keys are checked as each record of the reduce output is
+// collected, and once more when the reduce phase is complete. The collector does the evicting itself, between
+// records, so it never races with collection; evicted keys also drop out of JobHandle.Snapshot. Stats.EvictedKeys
+// counts them. A key that is evicted and then emitted again starts afresh. It has no effect on MapReduceStream, or
+// under WithSink, which deliver the reduce output as it is collected rather than keep it, so that there is nothing
+// to evict; a consumer that keeps keys of its own has to expire them itself.
+func WithKeyTTL(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.keyTTL = d
+	}
+}
+
+// keyTTL tracks when each key of a result was last touched, under WithKeyTTL.
+type keyTTL struct {
+	ttl time.Duration
+	// touched holds the keys in the order they were last touched, each with the time it was.
+	touched *list.List
+	keys    map[string]*list.Element
+}
+
+type touchedKey struct {
+	key string
+	at  time.Time
+}
+
+func newKeyTTL(ttl time.Duration) *keyTTL {
+	return &keyTTL{ttl: ttl, touched: list.New(), keys: make(map[string]*list.Element)}
+}
+
+// touch records that key was touched at now.
+func (t *keyTTL) touch(key string, now time.Time) {
+	if e, ok := t.keys[key]; ok {
+		e.Value = touchedKey{key: key, at: now}
+		t.touched.MoveToBack(e)
+		return
+	}
+	t.keys[key] = t.touched.PushBack(touchedKey{key: key, at: now})
+}
+
+// expire removes the keys last touched more than the TTL before now, and returns them.
+func (t *keyTTL) expire(now time.Time) []string {
+	var expired []string
+	for e := t.touched.Front(); e != nil && now.Sub(e.Value.(touchedKey).at) > t.ttl; e = t.touched.Front() {
+		key := e.Value.(touchedKey).key
+		t.touched.Remove(e)
+		delete(t.keys, key)
+		expired = append(expired, key)
+	}
+	return expired
+}
+
+// evictStale evicts the keys of results that ttl says have expired, and returns the bytes they held, as counted for
+// Stats.PeakIntermediateBytes. forget is called with each evicted key, for the collector to drop what else it keeps
+// about the key, so that the key starts afresh if it is emitted again.
+func (j *job) evictStale(results map[string][]string, ttl *keyTTL, forget func(key string)) (freed int64) {
+	for _, key := range ttl.expire(j.cfg.clock.Now()) {
+		freed += int64(len(key))
+		for _, value := range results[key] {
+			freed += int64(len(value))
+		}
+		delete(results, key)
+		forget(key)
+		delete(j.weights, key)
+		delete(j.meta, key)
+		if j.cfg.live != nil {
+			j.cfg.live.remove(key)
+		}
+		j.stats.EvictedKeys++
+	}
+	return freed
+}
@@ -0,0 +1,123 @@
+package mapreduce
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWithKeyTTL(t *testing.T) {
+	clock := &fakeClock{}
+	input := []MRInput{
+		{Key: "a", Values: []string{"x"}},
+		{Key: "b", Values: []string{"y1", "y2"}},
+		{Key: "c", Values: []string{"z"}},
+	}
+	// The reduce of "b" takes longer than the TTL between its values, so "a", collected before "b"'s first value, goes
+	// stale, while "b" and "c" are touched afterwards. The collector only takes "b"'s first value once it's done with
+	// "a", so "a" is touched before the clock moves.
+	reduceFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		for i, value := range input.Values {
+			if i == 1 {
+				clock.Advance(2 * time.Hour)
+			}
+			collectChl <- MRInput{Key: input.Key, Values: []string{value}}
+		}
+		doneChl <- struct{}{}
+	}
+
+	result, stats := MapReduceWithStats(input, IdentityMap, reduceFunc, WithKeyTTL(time.Hour), WithSequential(),
+		withClock(clock))
+	expected := map[string][]string{"b": {"y1", "y2"}, "c": {"z"}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+	if stats.EvictedKeys != 1 {
+		t.Errorf("Expected <1> evicted key; Got <%d>", stats.EvictedKeys)
+	}
+
+	result = MapReduce(input, IdentityMap, IdentityReduce, WithKeyTTL(time.Hour), withClock(&fakeClock{}))
+	if len(result) != len(input) {
+		t.Errorf("Expected every key kept while they're active; Got <%v>", result)
+	}
+}
+
+// ttlReemitReduce returns a reducer for the keys "a", "m" and "z", run in that order under WithSequential. "a" emits
+// first, the reduce of "m" outlasts a TTL of an hour, so that "a" is evicted, and "z" emits "a" anew.
+func ttlReemitReduce(clock *fakeClock, first, again []string) ReduceFunc {
+	return func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		switch input.Key {
+		case "a":
+			collectChl <- MRInput{Key: "a", Values: first}
+		case "m":
+			collectChl <- MRInput{Key: "m", Values: []string{"1"}}
+			clock.Advance(2 * time.Hour)
+			collectChl <- MRInput{Key: "m", Values: []string{"2"}}
+		case "z":
+			collectChl <- MRInput{Key: "a", Values: again}
+			collectChl <- MRInput{Key: "z", Values: []string{"w"}}
+		}
+		doneChl <- struct{}{}
+	}
+}
+
+func TestWithKeyTTLValueSet(t *testing.T) {
+	clock := &fakeClock{}
+	input := []MRInput{{Key: "a"}, {Key: "m"}, {Key: "z"}}
+
+	result := MapReduce(input, IdentityMap, ttlReemitReduce(clock, []string{"v"}, []string{"v"}), WithValueSet(),
+		WithKeyTTL(time.Hour), WithSequential(), withClock(clock))
+
+	// The evicted key's set of values is forgotten with it, so its value counts again.
+	expected := map[string][]string{"a": {"v"}, "m": {"1", "2"}, "z": {"w"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+}
+
+func TestWithKeyTTLMaxValuesPerKey(t *testing.T) {
+	clock := &fakeClock{}
+	input := []MRInput{{Key: "a"}, {Key: "m"}, {Key: "z"}}
+
+	result, warnings := MapReduceWithWarnings(input, IdentityMap,
+		ttlReemitReduce(clock, []string{"v1", "v2"}, []string{"v3", "v4"}), WithMaxValuesPerKey(1),
+		WithKeyTTL(time.Hour), WithSequential(), withClock(clock))
+
+	expected := map[string][]string{"a": {"v3"}, "m": {"1"}, "z": {"w"}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, result)
+	}
+	// "a" is truncated afresh once it's emitted again, as is "m".
+	if len(warnings) != 3 {
+		t.Errorf("Expected <3> truncation warnings; Got <%v>", warnings)
+	}
+}
+
+func TestWithKeyTTLStream(t *testing.T) {
+	clock := &fakeClock{}
+	input := []MRInput{{Key: "a", Values: []string{"x"}}, {Key: "b", Values: []string{"y1", "y2"}}}
+	reduceFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		for _, value := range input.Values {
+			collectChl <- MRInput{Key: input.Key, Values: []string{value}}
+			clock.Advance(2 * time.Hour)
+		}
+		doneChl <- struct{}{}
+	}
+
+	s := MapReduceStream(input, IdentityMap, reduceFunc, WithKeyTTL(time.Hour), WithSequential(), withClock(clock))
+	var streamed []MRInput
+	for kv := range s.C {
+		streamed = append(streamed, kv)
+	}
+	_, stats, err := s.Wait()
+
+	// Every record is delivered, however stale its key has gone since, and nothing is evicted.
+	expected := []MRInput{{Key: "a", Values: []string{"x"}}, {Key: "b", Values: []string{"y1"}},
+		{Key: "b", Values: []string{"y2"}}}
+	if err != nil || !reflect.DeepEqual(expected, streamed) {
+		t.Errorf("Expected <%v>; Got <%v>, <%v>", expected, streamed, err)
+	}
+	if stats.EvictedKeys != 0 {
+		t.Errorf("Expected no evicted keys; Got <%d>", stats.EvictedKeys)
+	}
+}
@@ -0,0 +1,94 @@
+package mapreduce
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// validateTimeout is how long ValidateMapFunc and ValidateReduceFunc wait for a function to signal done and return.
+var validateTimeout = 5 * time.Second
+
+// ValidateMapFunc checks that f honors the contract of a MapFunc, by running it on a synthetic input of one line of
+// text, and returns an error describing each violation it sees: panicking, never signalling done, signalling done
+// more than once, emitting after signalling done, and blocking without returning, with or without having signalled
+// done. It waits validateTimeout, 5 seconds, before concluding that f is blocked. It can only catch violations for
+// its input, and those of goroutines that f leaves running once it has returned only if they happen within the
+// timeout.
+func ValidateMapFunc(f MapFunc) error {
+	return validateFunc(mapPhase, f, MRInput{Key: "validate", Values: []string{"the quick brown fox"}})
+}
+
+// ValidateReduceFunc is the counterpart of ValidateMapFunc for a ReduceFunc, which it runs on a synthetic key with
+// the values "1" and "2".
+func ValidateReduceFunc(f ReduceFunc) error {
+	return validateFunc(reducePhase, f, MRInput{Key: "validate", Values: []string{"1", "2"}})
+}
+
+// validateFunc runs f on input and reports how it breaks the contract of a map or reduce function.
+func validateFunc(phase string, f func(MRInput, chan MRInput, chan struct{}), input MRInput) error {
+	collectChl := make(chan MRInput)
+	// Room for a second signal, so that it can be noticed.
+	doneChl := make(chan struct{}, 2)
+	// Receives the value f panicked with, or nil once it has returned normally.
+	returned := make(chan interface{}, 1)
+	go func() {
+		defer func() { returned <- recover() }()
+		f(input, collectChl, doneChl)
+	}()
+
+	var errs []error
+	violation := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("mapreduce: %s function "+format, append([]interface{}{phase}, args...)...))
+	}
+	timeout := time.After(validateTimeout)
+	done, hasReturned, emittedAfterDone := false, false, false
+	for !done || !hasReturned {
+		select {
+		case <-collectChl:
+			if done && !emittedAfterDone {
+				emittedAfterDone = true
+				violation("emitted after signalling done")
+			}
+		case <-doneChl:
+			if done {
+				violation("signalled done more than once")
+			}
+			done = true
+		case r := <-returned:
+			if r != nil {
+				violation("panicked: %v", r)
+				return errors.Join(errs...)
+			}
+			hasReturned = true
+		case <-timeout:
+			switch {
+			case !done && !hasReturned:
+				violation("blocked for %v without signalling done", validateTimeout)
+			case !done:
+				violation("returned without signalling done within %v", validateTimeout)
+			default:
+				violation("didn't return within %v of signalling done", validateTimeout)
+			}
+			// Keep f from blocking on its channels for as long as it goes on running.
+			go func() {
+				for {
+					select {
+					case <-collectChl:
+					case <-doneChl:
+					case <-returned:
+						return
+					}
+				}
+			}()
+			return errors.Join(errs...)
+		}
+	}
+	// A second signal sent just before returning may not have been received yet.
+	select {
+	case <-doneChl:
+		violation("signalled done more than once")
+	default:
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,79 @@
+package mapreduce
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateCompliantFuncs(t *testing.T) {
+	if err := ValidateMapFunc(wordMap); err != nil {
+		t.Errorf("Unexpected error <%v>", err)
+	}
+	if err := ValidateReduceFunc(countReduce); err != nil {
+		t.Errorf("Unexpected error <%v>", err)
+	}
+	// Signalling done from another goroutine, after returning, is allowed.
+	async := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		go IdentityReduce(input, collectChl, doneChl)
+	}
+	if err := ValidateReduceFunc(async); err != nil {
+		t.Errorf("Unexpected error <%v>", err)
+	}
+}
+
+func TestValidateNonCompliantFuncs(t *testing.T) {
+	defer func(timeout time.Duration) { validateTimeout = timeout }(validateTimeout)
+	validateTimeout = 10 * time.Millisecond
+	release := make(chan struct{})
+	defer close(release)
+
+	tests := map[string]struct {
+		f        MapFunc
+		expected string
+	}{
+		"no done": {
+			func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {},
+			"map function returned without signalling done",
+		},
+		"double done": {
+			func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+				doneChl <- struct{}{}
+				doneChl <- struct{}{}
+			},
+			"map function signalled done more than once",
+		},
+		"blocks": {
+			func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+				<-release
+			},
+			"map function blocked for 10ms without signalling done",
+		},
+		"blocks after done": {
+			func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+				doneChl <- struct{}{}
+				<-release
+			},
+			"map function didn't return within 10ms of signalling done",
+		},
+		"emits after done": {
+			func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+				doneChl <- struct{}{}
+				collectChl <- input
+			},
+			"map function emitted after signalling done",
+		},
+		"panics": {
+			func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+				panic("boom")
+			},
+			"map function panicked: boom",
+		},
+	}
+	for name, test := range tests {
+		err := ValidateMapFunc(test.f)
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("%s: Expected <%s>; Got <%v>", name, test.expected, err)
+		}
+	}
+}
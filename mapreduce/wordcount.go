@@ -0,0 +1,117 @@
+package mapreduce
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxWordCountBatch is the most lines StreamingWordCount counts in one job.
+const maxWordCountBatch = 1024
+
+// StreamingWordCount counts the words of r, as split by strings.Fields, while it is being read, and sends the running
+// count of each word on the returned channel every time it changes, as a record with the word as its key and the
+// count so far as its only value. So the last record of a word holds its count over the whole of r. The lines are
+// counted in batches of those read so far, each by a streaming job whose mappers combine the counts of a line's
+// repeated words via WithEmitCoalesce, so a reader that blocks doesn't hold back the counts of the lines before it.
+// The channel is closed once r is exhausted, or fails, or is too long a line for a bufio.Scanner, or once ctx is
+// cancelled; the caller must receive from it until then. As with DecodeJSONLinesContext, a read from r that is under
+// way when ctx is cancelled isn't interrupted. It returns an error only if r is nil.
+func StreamingWordCount(ctx context.Context, r io.Reader) (<-chan MRInput, error) {
+	if r == nil {
+		return nil, errors.New("mapreduce: StreamingWordCount needs a reader")
+	}
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	out := make(chan MRInput)
+	go func() {
+		defer close(out)
+		counts := make(map[string]int)
+		for n := 1; ; {
+			batch := nextLines(ctx, lines, n)
+			if len(batch) == 0 {
+				return
+			}
+			n += len(batch)
+			s := MapReduceStreamContext(ctx, batch, wordCountMap, SumIntReduce,
+				WithEmitCoalesce(func(a, b string) string {
+					x, _ := strconv.Atoi(a)
+					y, _ := strconv.Atoi(b)
+					return strconv.Itoa(x + y)
+				}))
+			for kv := range s.C {
+				count, _ := strconv.Atoi(kv.Values[0])
+				counts[kv.Key] += count
+				select {
+				case out <- MRInput{Key: kv.Key, Values: []string{strconv.Itoa(counts[kv.Key])}}:
+				case <-ctx.Done():
+					// The job stops as well, so C is closed soon.
+					Drain(s.C)
+				}
+			}
+			if _, _, err := s.Wait(); err != nil {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// nextLines waits for a line from lines, then takes whatever more are ready without waiting, up to
+// maxWordCountBatch, and returns them as inputs keyed by their line numbers, counting from first. It returns nothing
+// once lines is closed or ctx is cancelled.
+func nextLines(ctx context.Context, lines <-chan string, first int) []MRInput {
+	var batch []MRInput
+	add := func(line string) {
+		batch = append(batch, MRInput{Key: strconv.Itoa(first + len(batch)), Values: []string{line}})
+	}
+	select {
+	case line, ok := <-lines:
+		if !ok {
+			return nil
+		}
+		add(line)
+	case <-ctx.Done():
+		return nil
+	}
+	for len(batch) < maxWordCountBatch {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return batch
+			}
+			add(line)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+// wordCountMap emits each word of its input's lines with a count of 1, in sorted order, so that WithEmitCoalesce
+// combines the repeats of a word into one record.
+func wordCountMap(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+	for _, line := range input.Values {
+		words := strings.Fields(line)
+		sort.Strings(words)
+		for _, word := range words {
+			EmitInt(collectChl, word, 1)
+		}
+	}
+	doneChl <- struct{}{}
+}
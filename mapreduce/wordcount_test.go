@@ -0,0 +1,88 @@
+package mapreduce
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// finalCounts receives running counts from counts until it is closed and returns the last count of each word.
+func finalCounts(counts <-chan MRInput) map[string]string {
+	final := make(map[string]string)
+	for kv := range counts {
+		final[kv.Key] = kv.Values[0]
+	}
+	return final
+}
+
+func TestStreamingWordCount(t *testing.T) {
+	text := "the cat sat on the mat\n\nthe dog sat\nthe end the end\n"
+	counts, err := StreamingWordCount(context.Background(), strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("Expected no error; Got <%v>", err)
+	}
+
+	expected := map[string]string{"the": "5", "cat": "1", "sat": "2", "on": "1", "mat": "1", "dog": "1", "end": "2"}
+	if final := finalCounts(counts); !reflect.DeepEqual(expected, final) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, final)
+	}
+
+	if _, err := StreamingWordCount(context.Background(), nil); err == nil {
+		t.Errorf("Expected an error for a nil reader")
+	}
+}
+
+func TestStreamingWordCountBlockingReader(t *testing.T) {
+	pr, pw := io.Pipe()
+	counts, _ := StreamingWordCount(context.Background(), pr)
+
+	// The count of the first line arrives while the reader is blocked waiting for more.
+	go pw.Write([]byte("hello world hello\n"))
+	for seen := map[string]string{}; seen["hello"] != "2" || seen["world"] != "1"; {
+		select {
+		case kv := <-counts:
+			seen[kv.Key] = kv.Values[0]
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected counts of the first line before the reader is done; Got <%v>", seen)
+		}
+	}
+
+	go func() {
+		pw.Write([]byte("hello again\n"))
+		pw.Close()
+	}()
+	expected := map[string]string{"hello": "3", "again": "1"}
+	if final := finalCounts(counts); !reflect.DeepEqual(expected, final) {
+		t.Errorf("Expected <%v>; Got <%v>", expected, final)
+	}
+}
+
+func TestStreamingWordCountCancelled(t *testing.T) {
+	before := runtime.NumGoroutine()
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	counts, _ := StreamingWordCount(ctx, pr)
+
+	go pw.Write([]byte("a b c\n"))
+	<-counts
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		Drain(counts)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the counts to be closed once the context is cancelled")
+	}
+	// The reader goroutine is blocked in a read until the pipe is closed.
+	pw.Close()
+	waitForGoroutines(t, before)
+}
@@ -0,0 +1,32 @@
+package mapreduce
+
+import (
+	"io"
+	"sync"
+)
+
+// SafeWriter serializes writes to an underlying io.Writer so that many reducers can share it. Each call to Write
+// reaches the underlying writer in one piece, so as long as a reducer writes a whole record per call, records from
+// different reducers never interleave. Give it to reducers by capturing it in a closure, e.g.:
+//
+//	w := NewSafeWriter(f)
+//	reduceFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+//		fmt.Fprintf(w, "%s\t%v\n", input.Key, input.Values)
+//		doneChl <- struct{}{}
+//	}
+type SafeWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewSafeWriter returns a SafeWriter that writes to w.
+func NewSafeWriter(w io.Writer) *SafeWriter {
+	return &SafeWriter{w: w}
+}
+
+// Write writes p to the underlying writer while holding the SafeWriter's lock.
+func (sw *SafeWriter) Write(p []byte) (int, error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.Write(p)
+}
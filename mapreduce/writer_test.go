@@ -0,0 +1,69 @@
+package mapreduce
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// chunkedWriter writes its input a byte at a time, making any unsynchronized concurrent use show up as garbled lines.
+type chunkedWriter struct {
+	buf bytes.Buffer
+}
+
+func (cw *chunkedWriter) Write(p []byte) (int, error) {
+	for i := range p {
+		cw.buf.WriteByte(p[i])
+	}
+	return len(p), nil
+}
+
+func TestSafeWriterConcurrentWrites(t *testing.T) {
+	const writers, lines = 50, 200
+	cw := &chunkedWriter{}
+	sw := NewSafeWriter(cw)
+
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for l := 0; l < lines; l++ {
+				fmt.Fprintf(sw, "writer-%03d line-%03d\n", w, l)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSuffix(cw.buf.String(), "\n"), "\n") {
+		var w, l int
+		if n, err := fmt.Sscanf(line, "writer-%03d line-%03d", &w, &l); n != 2 || err != nil {
+			t.Fatalf("Garbled line <%s>", line)
+		}
+		seen[line] = true
+	}
+	if len(seen) != writers*lines {
+		t.Errorf("Expected <%d> distinct lines; Got <%d>", writers*lines, len(seen))
+	}
+}
+
+func TestSafeWriterFromReducers(t *testing.T) {
+	var buf bytes.Buffer
+	sw := NewSafeWriter(&buf)
+	reduceFunc := func(input MRInput, collectChl chan MRInput, doneChl chan struct{}) {
+		fmt.Fprintf(sw, "%s=%d\n", input.Key, len(input.Values))
+		doneChl <- struct{}{}
+	}
+
+	result := MapReduce(numberedInputs(100), wordMap, reduceFunc)
+
+	if len(result) != 0 {
+		t.Errorf("Expected reducers to write instead of emitting; Got <%v>", result)
+	}
+	if n := strings.Count(buf.String(), "=1\n"); n != 100 {
+		t.Errorf("Expected 100 written records; Got <%d>", n)
+	}
+}